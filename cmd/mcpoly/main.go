@@ -6,8 +6,8 @@ import (
 	"fmt"
 	"os"
 
-	"github.com/cherrydra/mcpurl/cmd/mcpoly/config"
 	"github.com/cherrydra/mcpurl/cmd/mcpoly/server"
+	"github.com/cherrydra/mcpurl/config"
 )
 
 var configFile string
@@ -11,7 +11,7 @@ import (
 	"strings"
 	"sync"
 
-	"github.com/cherrydra/mcpurl/cmd/mcpoly/config"
+	"github.com/cherrydra/mcpurl/config"
 	"github.com/cherrydra/mcpurl/mcp/transport"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -42,13 +42,19 @@ func (s *ReverseProxy) AddBackends(ctx context.Context, servers map[string]confi
 			cmd.Stderr = os.Stderr
 			cs, err = s.c.Connect(ctx, mcp.NewCommandTransport(cmd))
 		case "http":
-			cs, err = s.c.Connect(ctx, mcp.NewStreamableClientTransport(v.URL, &mcp.StreamableClientTransportOptions{
-				HTTPClient: &http.Client{Transport: &transport.AddHeadersRoundTripper{Headers: v.Headers.Encode()}},
-			}))
+			var httpClient *http.Client
+			if httpClient, err = transport.HTTPClient(v.Headers.Headers(), v.TLS); err == nil {
+				cs, err = s.c.Connect(ctx, mcp.NewStreamableClientTransport(v.URL, &mcp.StreamableClientTransportOptions{
+					HTTPClient: httpClient,
+				}))
+			}
 		case "sse":
-			cs, err = s.c.Connect(ctx, mcp.NewSSEClientTransport(v.URL, &mcp.SSEClientTransportOptions{
-				HTTPClient: &http.Client{Transport: &transport.AddHeadersRoundTripper{Headers: v.Headers.Encode()}},
-			}))
+			var httpClient *http.Client
+			if httpClient, err = transport.HTTPClient(v.Headers.Headers(), v.TLS); err == nil {
+				cs, err = s.c.Connect(ctx, mcp.NewSSEClientTransport(v.URL, &mcp.SSEClientTransportOptions{
+					HTTPClient: httpClient,
+				}))
+			}
 		default:
 			err = errors.New("unsupported server type: " + v.Type)
 		}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cherrydra/mcpurl/config"
+	"github.com/cherrydra/mcpurl/mcp/client"
+	"github.com/cherrydra/mcpurl/mcp/features"
+	"github.com/cherrydra/mcpurl/mcp/transport"
+	"github.com/cherrydra/mcpurl/parser"
+)
+
+const bashCompletion = `# bash completion for mcpurl, load with: source <(mcpurl completion bash)
+_mcpurl() {
+    local cur words cword
+    # bash splits the words on = and :, join them back so that key=value and uris reach mcpurl whole
+    if declare -F _get_comp_words_by_ref >/dev/null; then
+        _get_comp_words_by_ref -n =: cur words cword
+    else
+        cur=${COMP_WORDS[COMP_CWORD]} words=("${COMP_WORDS[@]}") cword=$COMP_CWORD
+    fi
+    local IFS=$'\n'
+    local candidates=($(mcpurl __complete "${words[@]:1:cword}" 2>/dev/null))
+    COMPREPLY=($(compgen -W "${candidates[*]%%$'\t'*}" -- "$cur"))
+    if declare -F __ltrim_colon_completions >/dev/null; then
+        __ltrim_colon_completions "$cur"
+    fi
+}
+complete -o default -F _mcpurl mcpurl
+`
+
+const zshCompletion = `#compdef mcpurl
+# zsh completion for mcpurl, load with: source <(mcpurl completion zsh)
+_mcpurl() {
+    local -a candidates described
+    local line value desc
+    candidates=("${(@f)$(mcpurl __complete "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+    for line in $candidates; do
+        [[ -z $line ]] && continue
+        value=${line%%$'\t'*}
+        desc=${line#*$'\t'}
+        [[ $desc == $line ]] && desc=""
+        described+=("${value//:/\\:}:$desc")
+    done
+    if (( ${#described} == 0 )); then
+        _files
+        return
+    fi
+    _describe 'mcpurl' described
+}
+compdef _mcpurl mcpurl
+`
+
+const fishCompletion = `# fish completion for mcpurl, load with: mcpurl completion fish | source
+function __mcpurl_complete
+    set -l tokens (commandline -opc) (commandline -ct)
+    mcpurl __complete $tokens[2..-1] 2>/dev/null
+end
+complete -c mcpurl -a '(__mcpurl_complete)'
+`
+
+func printCompletion(shell string) error {
+	switch shell {
+	case "bash":
+		fmt.Print(bashCompletion)
+	case "zsh":
+		fmt.Print(zshCompletion)
+	case "fish":
+		fmt.Print(fishCompletion)
+	default:
+		return fmt.Errorf("unsupported shell: %s", shell)
+	}
+	return nil
+}
+
+// complete prints the candidates for the last word, one per line with an optional tab separated description.
+// Tool, prompt and resource names are listed from the live server given in the words.
+func complete(words []string) error {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	cur := words[len(words)-1]
+	var prev string
+	if len(words) > 1 {
+		prev = words[len(words)-2]
+	}
+
+	var candidates []string
+	switch {
+	case prev == "-t" || prev == "--tool":
+		candidates = completeFeatures(words[:len(words)-2], func(ctx context.Context, f features.ServerFeatures) (ret []string) {
+			tools, _ := f.ListTools(ctx)
+			for _, tool := range tools {
+				ret = append(ret, tool.Name+"\t"+firstLine(tool.Description))
+			}
+			return
+		})
+	case prev == "-p" || prev == "--prompt":
+		candidates = completeFeatures(words[:len(words)-2], func(ctx context.Context, f features.ServerFeatures) (ret []string) {
+			prompts, _ := f.ListPrompts(ctx)
+			for _, prompt := range prompts {
+				ret = append(ret, prompt.Name+"\t"+firstLine(prompt.Description))
+			}
+			return
+		})
+	case prev == "-r" || prev == "--resource":
+		candidates = completeFeatures(words[:len(words)-2], func(ctx context.Context, f features.ServerFeatures) (ret []string) {
+			resources, _ := f.ListResources(ctx)
+			for _, resource := range resources {
+				ret = append(ret, resource.URI+"\t"+cmp.Or(resource.Title, resource.Name))
+			}
+			return
+		})
+	case prev == "-l" || prev == "--log-level":
+		candidates = []string{"debug", "info", "warn", "error"}
+	case prev == "--llm":
+		conf, _ := config.Profiles(parserArgs(nil).ConfigFile)
+		candidates = slices.Sorted(maps.Keys(conf.LLMs))
+	case expectsValue(prev):
+		// let the shell complete files
+	case toolOf(words) != "" && !strings.HasPrefix(cur, "-"):
+		// request items following the tool name
+		tool := toolOf(words)
+		candidates = completeFeatures(withoutTool(words[:len(words)-1]), func(ctx context.Context, f features.ServerFeatures) (ret []string) {
+			tools, _ := f.ListTools(ctx)
+			for _, t := range tools {
+				if t.Name != tool || t.InputSchema == nil {
+					continue
+				}
+				for _, prop := range slices.Sorted(maps.Keys(t.InputSchema.Properties)) {
+					v := t.InputSchema.Properties[prop]
+					item := prop + "="
+					if v != nil && v.Type != "" && v.Type != "string" {
+						item = prop + ":="
+					}
+					desc := ""
+					if v != nil {
+						desc = cmp.Or(v.Description, v.Title, v.Type)
+					}
+					ret = append(ret, item+"\t"+firstLine(desc))
+				}
+			}
+			return
+		})
+	case strings.HasPrefix(cur, "-"):
+		candidates = flagCandidates()
+	case strings.HasPrefix(cur, "@"):
+		conf, _ := config.Profiles(parserArgs(nil).ConfigFile)
+		for _, name := range slices.Sorted(maps.Keys(conf.Servers)) {
+			server := conf.Servers[name]
+			candidates = append(candidates, "@"+name+"\t"+cmp.Or(server.URL, server.Command))
+		}
+	}
+	for _, c := range candidates {
+		if strings.HasPrefix(c, cur) {
+			fmt.Println(c)
+		}
+	}
+	return nil
+}
+
+func flagCandidates() (ret []string) {
+	for _, f := range parser.Flags {
+		if f.Short != "" {
+			ret = append(ret, f.Short+"\t"+f.Usage)
+		}
+		ret = append(ret, f.Long+"\t"+f.Usage)
+	}
+	return
+}
+
+func expectsValue(arg string) bool {
+	f, ok := parser.LookupFlag(arg)
+	return ok && f.Arg != ""
+}
+
+// toolOf returns the tool name if the last word follows -t/--tool <name> and request items only.
+func toolOf(words []string) string {
+	for i := len(words) - 2; i > 0; i-- {
+		if words[i-1] == "-t" || words[i-1] == "--tool" {
+			return words[i]
+		}
+		if !(parser.Parser{}).IsRequestItem(words[i]) {
+			return ""
+		}
+	}
+	return ""
+}
+
+// withoutTool drops the tool call and its request items from words, so that they only describe the server.
+func withoutTool(words []string) []string {
+	var ret []string
+	for i := 0; i < len(words); i++ {
+		if words[i] == "-t" || words[i] == "--tool" {
+			for i++; i+1 < len(words) && (parser.Parser{}).IsRequestItem(words[i+1]); i++ {
+			}
+			continue
+		}
+		ret = append(ret, words[i])
+	}
+	return ret
+}
+
+func parserArgs(words []string) parser.Arguments {
+	// the data is not read from stdin while completing
+	p := parser.Parser{Stdin: strings.NewReader("")}
+	_ = p.Parse(words)
+	return p.Arguments()
+}
+
+// completeFeatures connects to the server described by words and lists candidates from it.
+func completeFeatures(words []string, list func(ctx context.Context, f features.ServerFeatures) []string) []string {
+	args := parserArgs(words)
+	args.Silent = true
+	clientTransport, err := transport.Transport(args)
+	if err != nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	session, err := client.Connect(ctx, clientTransport)
+	if err != nil {
+		return nil
+	}
+	defer client.Close(session)
+	return list(ctx, features.ServerFeatures{Session: session})
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return line
+}
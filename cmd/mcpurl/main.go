@@ -1,21 +1,26 @@
 package main
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
 	"os"
+	"strings"
 
+	"github.com/cherrydra/mcpurl/inspector"
 	"github.com/cherrydra/mcpurl/interactor"
 	"github.com/cherrydra/mcpurl/interactor/commands"
 	"github.com/cherrydra/mcpurl/llm"
 	"github.com/cherrydra/mcpurl/mcp/client"
-	"github.com/cherrydra/mcpurl/mcp/features"
 	"github.com/cherrydra/mcpurl/mcp/transport"
 	"github.com/cherrydra/mcpurl/parser"
+	"github.com/cherrydra/mcpurl/stats"
+	"github.com/cherrydra/mcpurl/tui"
 	"github.com/cherrydra/mcpurl/version"
+	"github.com/mcpurl/readline"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/openai/openai-go"
 	"github.com/openai/openai-go/option"
@@ -44,6 +49,20 @@ func main() {
 		return
 	}
 
+	if parser.Arguments().Completion != "" {
+		runE(func() error {
+			return printCompletion(parser.Arguments().Completion)
+		})
+		return
+	}
+
+	if parser.Arguments().Complete {
+		runE(func() error {
+			return complete(parser.Arguments().CompleteWords)
+		})
+		return
+	}
+
 	runE(func() error {
 		return runMain(parser.Arguments())
 	})
@@ -67,13 +86,22 @@ func runMain(args parser.Arguments) error {
 		return fmt.Errorf("transport: %w", err)
 	}
 	ctx := context.Background()
+	if args.Time {
+		s := stats.Start()
+		ctx = stats.NewContext(ctx, s)
+		defer s.Report(os.Stderr)
+	}
+	var traffic *inspector.Traffic
+	if err == nil && args.Inspect {
+		traffic = inspector.NewTraffic()
+		clientTransport = mcp.NewLoggingTransport(clientTransport, traffic)
+	}
 	var session *mcp.ClientSession
 	if err == nil {
-		client := mcp.NewClient(client.Implementation, nil)
 		if session, err = client.Connect(ctx, clientTransport); err != nil {
 			return fmt.Errorf("connect mcp server: %w", err)
 		}
-		defer session.Close()
+		defer client.Close(session)
 	}
 
 	var L *llm.LLM
@@ -93,64 +121,121 @@ func runMain(args parser.Arguments) error {
 		Session: session,
 		LLM:     L,
 	}
+	if readline.IsTerminal(int(os.Stdin.Fd())) {
+		commands.Ask = askTerminal
+	}
 
 	if args.Interactive {
 		return (&interactor.Interactor{Commands: commands}).Run(ctx)
 	}
 
+	if args.ScriptFile != "" {
+		defer commands.Close()
+		err := (&interactor.Interactor{Commands: commands}).RunScript(ctx, args.ScriptFile, nil)
+		if errors.Is(err, os.ErrProcessDone) {
+			return nil
+		}
+		return err
+	}
+
 	if session == nil {
 		return parser.ErrInvalidUsage
 	}
 
+	if args.Inspect {
+		return (&inspector.Inspector{
+			Features: commands.Features(os.Stdout),
+			Server:   args.Server(),
+			Listen:   args.Listen,
+			Traffic:  traffic,
+			Yes:      args.Yes,
+		}).Run(ctx)
+	}
+
+	if args.TUI {
+		return (&tui.TUI{Features: commands.Features(os.Stdout), Server: args.Server(), Yes: args.Yes}).Run(ctx)
+	}
+
+	if args.Query != "" {
+		return query(ctx, commands, args.Query, func(out *os.File) error {
+			return runAction(ctx, commands, args, out)
+		})
+	}
+	return runAction(ctx, commands, args, os.Stdout)
+}
+
+func runAction(ctx context.Context, commands *commands.Commands, args parser.Arguments, out *os.File) error {
+	if args.Info {
+		return commands.Exec(ctx, "info", nil, os.Stdin, out, os.Stderr)
+	}
 	if args.Tools {
-		return commands.Exec(ctx, "tools", nil, os.Stdin, os.Stdout)
+		return commands.Exec(ctx, "tools", nil, os.Stdin, out, os.Stderr)
 	}
 	if args.Prompts {
-		return commands.Exec(ctx, "prompts", nil, os.Stdin, os.Stdout)
+		return commands.Exec(ctx, "prompts", nil, os.Stdin, out, os.Stderr)
 	}
 	if args.Resources {
-		return commands.Exec(ctx, "resources", nil, os.Stdin, os.Stdout)
+		return commands.Exec(ctx, "resources", nil, os.Stdin, out, os.Stderr)
 	}
 	if args.Tool != "" {
-		return (&features.ServerFeatures{Session: commands.Session}).CallTool(ctx, args.Tool, args.Data)
+		return commands.Features(out).CallTool(ctx, args.Tool, args.Data)
 	}
 	if args.Prompt != "" {
-		return (&features.ServerFeatures{Session: commands.Session}).GetPrompt(ctx, args.Prompt, args.Data)
+		return commands.Features(out).GetPrompt(ctx, args.Prompt, args.Data)
 	}
 	if args.Resource != "" {
-		return (&features.ServerFeatures{Session: commands.Session}).ReadResource(ctx, args.Resource)
+		return commands.Features(out).ReadResource(ctx, args.Resource)
 	}
 	if args.Msg != "" {
-		return commands.Exec(ctx, "msg", []string{args.Msg}, os.Stdin, os.Stdout)
+		return commands.Exec(ctx, "msg", []string{args.Msg}, os.Stdin, out, os.Stderr)
 	}
 	return parser.ErrInvalidUsage
 }
 
+// query runs the action with its output filtered by the json filter command.
+func query(ctx context.Context, commands *commands.Commands, path string, run func(out *os.File) error) error {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return fmt.Errorf("create pipe: %w", err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		defer r.Close()
+		done <- commands.Exec(ctx, "json", []string{path}, r, os.Stdout, os.Stderr)
+	}()
+	err = run(w)
+	w.Close()
+	if queryErr := <-done; err == nil {
+		err = queryErr
+	}
+	return err
+}
+
+func askTerminal(prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	return strings.TrimSpace(line), err
+}
+
 func printUsage() {
 	fmt.Println(`Usage:
   mcpurl <options> <mcp_server>
+  mcpurl inspect <mcp_server> [--listen 127.0.0.1:6274]
+  mcpurl completion bash|zsh|fish
 
 Accepted <options>:
-  -T, --tools                 List tools
-  -P, --prompts               List prompts
-  -R, --resources             List resources
-  -t, --tool <string>         Call tool
-  -p, --prompt <string>       Get prompt
-  -r, --resource <string>     Read resource
-  -d, --data <string/@file>   Send json data to server
-  -H, --header <header/@file> Pass custom header(s) to server
-  -h, --help                  Show this usage
-  -I, --interactive           Start interactive mode
-  -K, --llm-api-key <key>     API key for authenticating with the LLM
-  -L, --llm-base-url <url>    Base URL of the LLM service
-  -M, --llm-name <name>       Name of the LLM model to use
-  -l, --log-level <level>     Set log level (debug, info, warn, error)
-  -m, --msg <message>         Talk to LLM
-  -s, --silent                Silent mode
-  -v, --version               Show version
+` + parser.FlagUsage() + `
+Request items (following --tool/--prompt <name>):
+  key=value                   String argument
+  key:=json                   Raw json argument
+  key=@file                   String argument read from file
+  key:=@file.json             Json argument read from file
+  key=-                       String argument read from stdin
+  key:=-                      Json argument read from stdin
 
 Accepted <mcp_server> formats:
   https://example.com/mcp [options]
+  @profile [args]             (server profile from the config file)
   stdio:///path/to/mcpserver [args]
 
 Currently supported transports:
@@ -17,6 +17,22 @@ func (kv KV) Encode() []string {
 	return ret
 }
 
+// Headers encodes the kv as http headers.
+func (kv KV) Headers() []string {
+	var ret []string
+	for k, v := range kv {
+		ret = append(ret, fmt.Sprintf("%s: %s", k, v))
+	}
+	return ret
+}
+
+type TLS struct {
+	CAFile             string `json:"ca"`
+	CertFile           string `json:"cert"`
+	KeyFile            string `json:"key"`
+	InsecureSkipVerify bool   `json:"insecureSkipVerify"`
+}
+
 type Server struct {
 	Type    string   `json:"type"`
 	Command string   `json:"command"`
@@ -24,10 +40,18 @@ type Server struct {
 	Env     KV       `json:"env"`
 	URL     string   `json:"url"`
 	Headers KV       `json:"headers"`
+	TLS     *TLS     `json:"tls"`
+}
+
+type LLM struct {
+	BaseURL string `json:"baseURL"`
+	APIKey  string `json:"apiKey"`
+	Model   string `json:"model"`
 }
 
 type Config struct {
 	Servers map[string]Server `json:"servers"`
+	LLMs    map[string]LLM    `json:"llms"`
 }
 
 func Parse(file string) (*Config, error) {
@@ -47,10 +71,24 @@ func Parse(file string) (*Config, error) {
 			return &Config{}, nil
 		}
 	}
+	return parseFile(file)
+}
+
+// Profiles parses the mcpurl config file holding the named server and llm profiles,
+// a missing file is an empty config.
+func Profiles(file string) (*Config, error) {
+	if _, err := os.Stat(file); os.IsNotExist(err) {
+		return &Config{}, nil
+	}
+	return parseFile(file)
+}
+
+func parseFile(file string) (*Config, error) {
 	f, err := os.Open(file)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open config file: %w", err)
 	}
+	defer f.Close()
 	var conf Config
 	if err := json.NewDecoder(f).Decode(&conf); err != nil {
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
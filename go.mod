@@ -10,14 +10,14 @@ require (
 	github.com/mcpurl/readline v0.0.0-20250710153316-898675b77c88
 	github.com/modelcontextprotocol/go-sdk v0.2.0
 	github.com/openai/openai-go v1.8.2
+	github.com/tidwall/gjson v1.14.4
+	github.com/yosida95/uritemplate/v3 v3.0.2
 )
 
 require (
 	github.com/rivo/uniseg v0.2.0 // indirect
-	github.com/tidwall/gjson v1.14.4 // indirect
 	github.com/tidwall/match v1.1.1 // indirect
 	github.com/tidwall/pretty v1.2.1 // indirect
 	github.com/tidwall/sjson v1.2.5 // indirect
-	github.com/yosida95/uritemplate/v3 v3.0.2 // indirect
 	golang.org/x/sys v0.29.0 // indirect
 )
@@ -0,0 +1,350 @@
+"use strict";
+
+const $ = (selector) => document.querySelector(selector);
+
+// el creates an element with the attributes and children, strings are added as text.
+function el(tag, attrs = {}, ...children) {
+  const e = document.createElement(tag);
+  for (const [k, v] of Object.entries(attrs)) {
+    if (k.startsWith("on")) e.addEventListener(k.slice(2), v);
+    else if (v !== undefined && v !== null && v !== false) e.setAttribute(k, v === true ? "" : v);
+  }
+  e.append(...children.filter((c) => c !== undefined && c !== null));
+  return e;
+}
+
+async function api(path, body) {
+  const resp = await fetch("api/" + path, body === undefined ? {} : {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify(body),
+  });
+  if (resp.status === 204) return null;
+  const data = await resp.json();
+  if (!resp.ok) throw new Error(data.error || resp.statusText);
+  return data;
+}
+
+const pretty = (v) => JSON.stringify(v, null, 2);
+
+const state = { tab: "tools", items: {}, selected: null, log: "traffic", messages: [] };
+
+const tabs = {
+  tools: { name: (t) => t.name, desc: (t) => t.description },
+  prompts: { name: (p) => p.name, desc: (p) => p.description },
+  resources: { name: (r) => r.name || r.uri, desc: (r) => r.uri },
+  templates: { name: (t) => t.name || t.uriTemplate, desc: (t) => t.uriTemplate },
+};
+
+async function loadServer() {
+  const data = await api("server");
+  $("#server").textContent = data.server;
+  const info = data.initialization && data.initialization.Result && data.initialization.Result.serverInfo;
+  if (info) $("#info").textContent = `${info.name} ${info.version || ""}`;
+}
+
+async function loadList(tab, reload) {
+  if (reload || !state.items[tab]) {
+    try {
+      state.items[tab] = (await api(tab)) || [];
+    } catch (err) {
+      state.items[tab] = [];
+      showDetail(el("p", { class: "error" }, err.message));
+    }
+  }
+  if (tab === state.tab) renderList();
+}
+
+function renderList() {
+  const filter = $("#filter").value.toLowerCase();
+  const t = tabs[state.tab];
+  const list = $("#list");
+  list.replaceChildren();
+  for (const item of state.items[state.tab] || []) {
+    const name = t.name(item), desc = t.desc(item) || "";
+    if (filter && !(name + " " + desc).toLowerCase().includes(filter)) continue;
+    list.append(el("li", {
+      class: state.selected === item ? "selected" : null,
+      onclick: () => select(item),
+    }, name, el("small", {}, desc.split("\n")[0])));
+  }
+}
+
+function showDetail(...children) {
+  $("#detail").replaceChildren(...children);
+}
+
+function select(item) {
+  state.selected = item;
+  renderList();
+  const result = el("div", { id: "result" });
+  const views = { tools: toolView, prompts: promptView, resources: resourceView, templates: templateView };
+  showDetail(...views[state.tab](item, result), result,
+    el("details", {}, el("summary", {}, "Definition"), el("pre", {}, pretty(item))));
+}
+
+// Tools
+
+function hints(tool) {
+  // the hints missing from the annotations take their defaults of the spec
+  const a = tool.annotations || {};
+  const ret = [];
+  if (a.readOnlyHint) ret.push("read-only");
+  if (!a.readOnlyHint && a.destructiveHint !== false) ret.push("destructive");
+  if (a.idempotentHint) ret.push("idempotent");
+  if (a.openWorldHint !== false) ret.push("open-world");
+  return ret;
+}
+
+function toolView(tool, result) {
+  const form = schemaForm(tool.inputSchema || {}, "Call", async (args) => {
+    await run(result, (confirmed) => api("tools/call", { name: tool.name, arguments: args, confirmed }));
+  });
+  return [
+    el("h2", {}, tool.title || (tool.annotations && tool.annotations.title) || tool.name),
+    el("div", {}, ...hints(tool).map((h) => el("span", { class: "badge " + h }, h))),
+    el("p", {}, tool.description || ""),
+    form,
+  ];
+}
+
+// schemaForm returns a form of the properties of the object schema, the submitted values are parsed by their types.
+function schemaForm(schema, label, submit) {
+  const props = schema.properties || {};
+  const required = schema.required || [];
+  const names = Object.keys(props).sort((a, b) => required.includes(b) - required.includes(a));
+  const fields = names.map((name) => field(name, props[name] || {}, required.includes(name)));
+  const error = el("p", { class: "error" });
+  const form = el("form", {
+    onsubmit: async (e) => {
+      e.preventDefault();
+      error.textContent = "";
+      const values = {};
+      try {
+        for (const f of fields) {
+          const v = f.value();
+          if (v !== undefined) values[f.name] = v;
+        }
+      } catch (err) {
+        error.textContent = err.message;
+        return;
+      }
+      await submit(values);
+    },
+  }, ...fields.map((f) => f.element), error, el("button", { class: "primary", type: "submit" }, label));
+  return form;
+}
+
+function schemaType(schema) {
+  if (typeof schema.type === "string") return schema.type;
+  if (Array.isArray(schema.type)) return schema.type.find((t) => t !== "null") || "string";
+  if (schema.enum) return typeof schema.enum[0];
+  return "string";
+}
+
+// field returns the input of the property with a value function, undefined if it is left empty.
+function field(name, schema, required) {
+  const type = schemaType(schema);
+  const id = "field-" + name;
+  const placeholder = schema.default !== undefined ? "default " + JSON.stringify(schema.default) : "";
+  let input, value;
+  if (schema.enum) {
+    input = el("select", { id, required },
+      el("option", { value: "" }, ""),
+      ...schema.enum.map((v, i) => el("option", { value: i }, JSON.stringify(v))));
+    value = () => (input.value === "" ? undefined : schema.enum[input.value]);
+  } else if (type === "boolean") {
+    input = el("select", { id, required }, el("option", { value: "" }, ""),
+      el("option", { value: "true" }, "true"), el("option", { value: "false" }, "false"));
+    value = () => (input.value === "" ? undefined : input.value === "true");
+  } else if (type === "integer" || type === "number") {
+    input = el("input", { id, type: "number", step: type === "integer" ? 1 : "any", placeholder, required });
+    value = () => (input.value === "" ? undefined : Number(input.value));
+  } else if (type === "object" || type === "array") {
+    input = el("textarea", { id, placeholder: placeholder || (type === "array" ? "[]" : "{}"), required });
+    value = () => {
+      if (input.value.trim() === "") return undefined;
+      try {
+        return JSON.parse(input.value);
+      } catch (err) {
+        throw new Error(`${name}: expected json ${type}`);
+      }
+    };
+  } else {
+    input = el("input", { id, type: "text", placeholder, required });
+    value = () => (input.value === "" ? undefined : input.value);
+  }
+  const element = el("div", { class: "field" },
+    el("label", { for: id }, name + (required ? "*" : "") + " ", el("span", { class: "type" }, `(${type})`)),
+    input,
+    schema.description || schema.title ? el("span", { class: "desc" }, schema.description || schema.title) : null);
+  return { name, element, value };
+}
+
+// run shows the output of the call, destructive tool calls are confirmed before calling again.
+async function run(result, call) {
+  result.replaceChildren(el("p", { class: "hint" }, "Calling..."));
+  try {
+    let data = await call(false);
+    if (data.confirm) {
+      if (!window.confirm(data.confirm)) {
+        result.replaceChildren(el("p", { class: "error" }, data.error));
+        return;
+      }
+      data = await call(true);
+    }
+    result.replaceChildren(...outputOf(data.output));
+    if (data.error) result.append(el("p", { class: "error" }, "Error: " + data.error));
+  } catch (err) {
+    result.replaceChildren(el("p", { class: "error" }, err.message));
+  }
+}
+
+// outputOf pretty prints the json lines of the output.
+function outputOf(output) {
+  return output.split("\n").filter((l) => l.trim() !== "").map((l) => {
+    try {
+      return el("pre", {}, pretty(JSON.parse(l)));
+    } catch (err) {
+      return el("pre", {}, l);
+    }
+  });
+}
+
+// Prompts, resources and templates
+
+function stringSchema(args) {
+  const schema = { type: "object", properties: {}, required: [] };
+  for (const a of args) {
+    schema.properties[a.name] = { type: "string", description: a.description || a.title };
+    if (a.required) schema.required.push(a.name);
+  }
+  return schema;
+}
+
+function promptView(prompt, result) {
+  return [
+    el("h2", {}, prompt.title || prompt.name),
+    el("p", {}, prompt.description || ""),
+    schemaForm(stringSchema(prompt.arguments || []), "Get", async (args) => {
+      await run(result, () => api("prompts/get", { name: prompt.name, arguments: args }));
+    }),
+  ];
+}
+
+function resourceView(resource, result) {
+  return [
+    el("h2", {}, resource.title || resource.name || resource.uri),
+    el("p", {}, el("code", {}, resource.uri), " ", resource.mimeType ? el("span", { class: "badge" }, resource.mimeType) : null),
+    el("p", {}, resource.description || ""),
+    el("button", { class: "primary", onclick: () => run(result, () => api("resources/read", { uri: resource.uri })) }, "Read"),
+  ];
+}
+
+// templateVariables returns the variable names of the uri template.
+function templateVariables(template) {
+  const names = [];
+  for (const [, expr] of template.matchAll(/\{[+#./;?&]?([^}]+)\}/g)) {
+    for (const v of expr.split(",")) names.push(v.replace(/(\*|:\d+)$/, ""));
+  }
+  return names;
+}
+
+function templateView(template, result) {
+  const args = templateVariables(template.uriTemplate).map((name) => ({ name, required: true }));
+  return [
+    el("h2", {}, template.title || template.name),
+    el("p", {}, el("code", {}, template.uriTemplate)),
+    el("p", {}, template.description || ""),
+    schemaForm(stringSchema(args), "Read", async (args) => {
+      await run(result, () => api("resources/read", { uriTemplate: template.uriTemplate, arguments: args }));
+    }),
+  ];
+}
+
+// Traffic
+
+const isNotification = (m) => m.direction === "receive" && m.message && m.message.method && m.message.id === undefined;
+
+function summary(m) {
+  if (m.error) return m.error;
+  const msg = m.message;
+  if (msg.method) return msg.id !== undefined ? `${msg.method} #${msg.id}` : msg.method;
+  return msg.error ? `error #${msg.id}: ${msg.error.message}` : `result #${msg.id}`;
+}
+
+function logEntry(m) {
+  const time = new Date(m.time).toLocaleTimeString();
+  const arrow = { send: "→", receive: "←", error: "!" }[m.direction];
+  const head = [el("span", { class: "time" }, time), el("span", { class: "dir" }, arrow + " "), summary(m)];
+  if (!m.message) return el("li", { class: m.direction }, ...head);
+  return el("li", { class: m.direction },
+    el("details", {}, el("summary", {}, ...head), el("pre", {}, pretty(m.message))));
+}
+
+function renderLog() {
+  const shown = state.messages.filter((m) => state.log === "traffic" || isNotification(m));
+  $("#log").replaceChildren(...shown.map(logEntry));
+}
+
+function listen() {
+  const events = new EventSource("api/traffic");
+  events.onmessage = (e) => {
+    const m = JSON.parse(e.data);
+    if (state.messages.some((seen) => seen.seq === m.seq)) return;
+    state.messages.push(m);
+    if (state.messages.length > 1000) state.messages.shift();
+    if (state.log === "traffic" || isNotification(m)) {
+      const log = $("#log");
+      const atBottom = log.scrollTop + log.clientHeight >= log.scrollHeight - 5;
+      log.append(logEntry(m));
+      if (atBottom) log.scrollTop = log.scrollHeight;
+    }
+    if (isNotification(m)) listChanged(m.message.method);
+  };
+}
+
+// listChanged reloads the lists the server notified changes of.
+function listChanged(method) {
+  const changed = {
+    "notifications/tools/list_changed": ["tools"],
+    "notifications/prompts/list_changed": ["prompts"],
+    "notifications/resources/list_changed": ["resources", "templates"],
+  }[method] || [];
+  for (const tab of changed) {
+    if (state.items[tab]) loadList(tab, true);
+  }
+}
+
+// Wiring
+
+for (const b of document.querySelectorAll("#tabs button")) {
+  b.addEventListener("click", () => {
+    document.querySelectorAll("#tabs button").forEach((x) => x.classList.toggle("active", x === b));
+    state.tab = b.dataset.tab;
+    state.selected = null;
+    showDetail(el("p", { class: "hint" }, "Select an item on the left."));
+    loadList(state.tab);
+  });
+}
+for (const b of document.querySelectorAll("#logtabs button[data-log]")) {
+  b.addEventListener("click", () => {
+    document.querySelectorAll("#logtabs button[data-log]").forEach((x) => x.classList.toggle("active", x === b));
+    state.log = b.dataset.log;
+    renderLog();
+  });
+}
+$("#clear").addEventListener("click", () => {
+  state.messages = [];
+  renderLog();
+});
+$("#filter").addEventListener("input", renderList);
+$("#refresh").addEventListener("click", async () => {
+  await api("refresh", {});
+  state.items = {};
+  loadList(state.tab);
+});
+
+loadServer();
+loadList(state.tab);
+listen();
@@ -0,0 +1,289 @@
+// Package inspector serves a local web page for browsing and calling the features of a server,
+// with the live json-rpc traffic of the session.
+package inspector
+
+import (
+	"cmp"
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"regexp"
+	"strings"
+
+	"github.com/cherrydra/mcpurl/mcp/client"
+	"github.com/cherrydra/mcpurl/mcp/features"
+	"github.com/yosida95/uritemplate/v3"
+)
+
+const DefaultListen = "127.0.0.1:6274"
+
+//go:embed assets
+var assets embed.FS
+
+// Inspector serves the web page of a session.
+type Inspector struct {
+	Features features.ServerFeatures
+	// Server is the display name of the server.
+	Server string
+	// Listen is the address to listen on, DefaultListen if empty.
+	Listen string
+	// Traffic is the recorded traffic of the session, the traffic log is empty if nil.
+	Traffic *Traffic
+	// Yes calls destructive tools without confirmation.
+	Yes bool
+}
+
+// Run serves until interrupted.
+func (in *Inspector) Run(ctx context.Context) error {
+	if in.Traffic == nil {
+		in.Traffic = NewTraffic()
+	}
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	l, err := net.Listen("tcp", cmp.Or(in.Listen, DefaultListen))
+	if err != nil {
+		return fmt.Errorf("listen: %w", err)
+	}
+	srv := &http.Server{Handler: in.Handler()}
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+	fmt.Fprintf(os.Stderr, "Inspecting %s at http://%s, press Ctrl-C to stop\n", in.Server, l.Addr())
+	if err := srv.Serve(l); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("serve: %w", err)
+	}
+	return nil
+}
+
+// Handler returns the handler of the page and its api.
+func (in *Inspector) Handler() http.Handler {
+	static, _ := fs.Sub(assets, "assets")
+	mux := http.NewServeMux()
+	mux.Handle("GET /", http.FileServerFS(static))
+	mux.HandleFunc("GET /api/server", in.server)
+	mux.HandleFunc("GET /api/tools", list(in.Features.ListTools))
+	mux.HandleFunc("GET /api/prompts", list(in.Features.ListPrompts))
+	mux.HandleFunc("GET /api/resources", list(in.Features.ListResources))
+	mux.HandleFunc("GET /api/templates", list(in.Features.ListResourceTemplates))
+	mux.HandleFunc("GET /api/traffic", in.traffic)
+	mux.HandleFunc("POST /api/refresh", in.refresh)
+	mux.HandleFunc("POST /api/tools/call", in.callTool)
+	mux.HandleFunc("POST /api/prompts/get", in.getPrompt)
+	mux.HandleFunc("POST /api/resources/read", in.readResource)
+	return guard(mux)
+}
+
+// guard refuses the requests of other sites and of rebound dns names, as the api calls tools for the user.
+func guard(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = r.Host
+		}
+		if host != "localhost" && net.ParseIP(host) == nil {
+			writeError(w, http.StatusForbidden, fmt.Errorf("host %s refused", host))
+			return
+		}
+		if origin := r.Header.Get("Origin"); origin != "" && origin != "http://"+r.Host {
+			writeError(w, http.StatusForbidden, fmt.Errorf("origin %s refused", origin))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (in *Inspector) server(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"server":         in.Server,
+		"initialization": client.Initialized(in.Features.Session),
+	})
+}
+
+func list[T any](list func(ctx context.Context) ([]T, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		items, err := list(r.Context())
+		if err != nil {
+			writeError(w, http.StatusBadGateway, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, items)
+	}
+}
+
+func (in *Inspector) refresh(w http.ResponseWriter, r *http.Request) {
+	in.Features.Refresh()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// traffic streams the recorded and following messages as server-sent events.
+func (in *Inspector) traffic(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, errors.New("streaming unsupported"))
+		return
+	}
+	backlog, messages, cancel := in.Traffic.subscribe()
+	defer cancel()
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	send := func(m Message) {
+		data, _ := json.Marshal(m)
+		fmt.Fprintf(w, "data: %s\n\n", data)
+	}
+	for _, m := range backlog {
+		send(m)
+	}
+	flusher.Flush()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case m := <-messages:
+			send(m)
+			flusher.Flush()
+		}
+	}
+}
+
+// callResult is the output of a call, confirm is the question to confirm a destructive tool call with.
+type callResult struct {
+	Output  string `json:"output"`
+	Error   string `json:"error,omitempty"`
+	Confirm string `json:"confirm,omitempty"`
+}
+
+func (in *Inspector) callTool(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name      string         `json:"name"`
+		Arguments map[string]any `json:"arguments"`
+		Confirmed bool           `json:"confirmed"`
+	}
+	if !readJSON(w, r, &req) {
+		return
+	}
+	var confirm string
+	result := in.call(func(f features.ServerFeatures) error {
+		f.Confirm = func(prompt string) (bool, error) {
+			confirm = prompt
+			return in.Yes || req.Confirmed, nil
+		}
+		return f.CallTool1(r.Context(), req.Name, req.Arguments)
+	})
+	if errors.Is(result.err, features.ErrNotConfirmed) && confirm != "" {
+		result.Confirm = strings.TrimSuffix(strings.TrimSpace(stripANSI(confirm)), " [y/N]")
+	}
+	writeJSON(w, http.StatusOK, result.callResult)
+}
+
+func (in *Inspector) getPrompt(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name      string            `json:"name"`
+		Arguments map[string]string `json:"arguments"`
+	}
+	if !readJSON(w, r, &req) {
+		return
+	}
+	result := in.call(func(f features.ServerFeatures) error {
+		return f.GetPrompt1(r.Context(), req.Name, req.Arguments)
+	})
+	writeJSON(w, http.StatusOK, result.callResult)
+}
+
+// readResource reads the resource of the uri, or of the uri template expanded with the arguments.
+func (in *Inspector) readResource(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		URI         string            `json:"uri"`
+		URITemplate string            `json:"uriTemplate"`
+		Arguments   map[string]string `json:"arguments"`
+	}
+	if !readJSON(w, r, &req) {
+		return
+	}
+	if req.URITemplate != "" {
+		uri, err := expandTemplate(req.URITemplate, req.Arguments)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		req.URI = uri
+	}
+	result := in.call(func(f features.ServerFeatures) error {
+		return f.ReadResource(r.Context(), req.URI)
+	})
+	writeJSON(w, http.StatusOK, result.callResult)
+}
+
+type capturedCall struct {
+	callResult
+	err error
+}
+
+// call runs the call with its output captured.
+func (in *Inspector) call(run func(f features.ServerFeatures) error) capturedCall {
+	r, w, err := os.Pipe()
+	if err != nil {
+		err = fmt.Errorf("create pipe: %w", err)
+		return capturedCall{callResult{Error: err.Error()}, err}
+	}
+	output := make(chan []byte, 1)
+	go func() {
+		defer r.Close()
+		b, _ := io.ReadAll(r)
+		output <- b
+	}()
+	f := in.Features
+	f.Out = w
+	err = run(f)
+	w.Close()
+	ret := capturedCall{callResult{Output: string(<-output)}, err}
+	if err != nil {
+		ret.Error = err.Error()
+	}
+	return ret
+}
+
+func expandTemplate(template string, arguments map[string]string) (string, error) {
+	t, err := uritemplate.New(template)
+	if err != nil {
+		return "", fmt.Errorf("parse uri template: %w", err)
+	}
+	values := uritemplate.Values{}
+	for name, v := range arguments {
+		values.Set(name, uritemplate.String(v))
+	}
+	return t.Expand(values)
+}
+
+var ansi = regexp.MustCompile("\033\\[[0-9;]*m")
+
+func stripANSI(s string) string {
+	return ansi.ReplaceAllString(s, "")
+}
+
+func readJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("decode request: %w", err))
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,75 @@
+package inspector
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// maxMessages is the number of messages kept for the pages opened later.
+const maxMessages = 1000
+
+// Message is a json-rpc message sent to or received from the server.
+type Message struct {
+	Seq       int             `json:"seq"`
+	Time      time.Time       `json:"time"`
+	Direction string          `json:"direction"` // "send", "receive" or "error"
+	Message   json.RawMessage `json:"message,omitempty"`
+	Error     string          `json:"error,omitempty"`
+}
+
+// Traffic records the json-rpc messages of a session, it is the writer of an [mcp.LoggingTransport].
+type Traffic struct {
+	mu          sync.Mutex
+	seq         int
+	messages    []Message
+	subscribers map[chan Message]struct{}
+}
+
+func NewTraffic() *Traffic {
+	return &Traffic{subscribers: map[chan Message]struct{}{}}
+}
+
+// Write records a line written by the logging transport, either "read: <json>", "write: <json>" or an error.
+func (t *Traffic) Write(p []byte) (int, error) {
+	line := bytes.TrimSpace(p)
+	m := Message{Time: time.Now()}
+	if data, ok := bytes.CutPrefix(line, []byte("read: ")); ok && json.Valid(data) {
+		m.Direction, m.Message = "receive", bytes.Clone(data)
+	} else if data, ok := bytes.CutPrefix(line, []byte("write: ")); ok && json.Valid(data) {
+		m.Direction, m.Message = "send", bytes.Clone(data)
+	} else {
+		m.Direction, m.Error = "error", string(line)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.seq++
+	m.Seq = t.seq
+	t.messages = append(t.messages, m)
+	if len(t.messages) > maxMessages {
+		t.messages = t.messages[len(t.messages)-maxMessages:]
+	}
+	for ch := range t.subscribers {
+		// slow pages miss messages rather than blocking the session
+		select {
+		case ch <- m:
+		default:
+		}
+	}
+	return len(p), nil
+}
+
+// subscribe returns the recorded messages and a channel of the following ones, until cancelled.
+func (t *Traffic) subscribe() ([]Message, <-chan Message, func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	ch := make(chan Message, 256)
+	t.subscribers[ch] = struct{}{}
+	return append([]Message(nil), t.messages...), ch, func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		delete(t.subscribers, ch)
+	}
+}
@@ -0,0 +1,137 @@
+package interactor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/cherrydra/mcpurl/interactor/shell"
+	"github.com/cherrydra/mcpurl/parser"
+)
+
+// aliasesKey is the context key of the aliases being expanded, an alias is not expanded within itself.
+type aliasesKey struct{}
+
+func (ia *Interactor) alias(ctx context.Context, name string) (string, bool) {
+	if expanding, _ := ctx.Value(aliasesKey{}).([]string); slices.Contains(expanding, name) {
+		return "", false
+	}
+	ia.mu.Lock()
+	defer ia.mu.Unlock()
+	value, ok := ia.aliases[name]
+	return value, ok
+}
+
+func (ia *Interactor) aliasNames() []string {
+	ia.mu.Lock()
+	defer ia.mu.Unlock()
+	return slices.Sorted(maps.Keys(ia.aliases))
+}
+
+// runAlias runs the command line of the alias with the arguments appended to its last command.
+func (ia *Interactor) runAlias(ctx context.Context, name, value string, args []string, std stdio) error {
+	list, err := shell.Parse(value)
+	if err != nil {
+		// not wrapped, the column is not the one of the command line
+		return fmt.Errorf("alias %s: %v", name, err)
+	}
+	if len(list.Items) == 0 {
+		return nil
+	}
+	last := &list.Items[len(list.Items)-1].Pipeline
+	command := &last.Commands[len(last.Commands)-1]
+	for _, arg := range args {
+		command.Words = append(command.Words, shell.Word{Parts: []shell.Part{{Kind: shell.Literal, Text: arg}}})
+	}
+	expanding, _ := ctx.Value(aliasesKey{}).([]string)
+	ctx = context.WithValue(ctx, aliasesKey{}, append(slices.Clip(expanding), name))
+	return ia.runList(ctx, list, std)
+}
+
+// defineAliases defines the name=value aliases, or prints the aliases without arguments.
+func (ia *Interactor) defineAliases(args []string, out io.Writer) error {
+	if len(args) == 0 {
+		for _, name := range ia.aliasNames() {
+			ia.printAlias(out, name)
+		}
+		return nil
+	}
+	var errs []error
+	for _, arg := range args {
+		name, value, ok := strings.Cut(arg, "=")
+		if !ok {
+			ia.mu.Lock()
+			_, found := ia.aliases[name]
+			ia.mu.Unlock()
+			if !found {
+				errs = append(errs, fmt.Errorf("alias %s: not found", name))
+				continue
+			}
+			ia.printAlias(out, name)
+			continue
+		}
+		if name == "" || strings.ContainsAny(name, " \t'\"$=|&;<>()") {
+			errs = append(errs, fmt.Errorf("alias %s: invalid name", name))
+			continue
+		}
+		if _, err := shell.Parse(value); err != nil {
+			errs = append(errs, fmt.Errorf("alias %s: %v", name, err))
+			continue
+		}
+		ia.mu.Lock()
+		if ia.aliases == nil {
+			ia.aliases = map[string]string{}
+		}
+		ia.aliases[name] = value
+		ia.mu.Unlock()
+	}
+	return errors.Join(errs...)
+}
+
+// printAlias prints the alias as it is defined, so that the listing can be used as a script.
+func (ia *Interactor) printAlias(out io.Writer, name string) {
+	ia.mu.Lock()
+	value := ia.aliases[name]
+	ia.mu.Unlock()
+	fmt.Fprintf(out, "alias %s='%s'\n", name, strings.ReplaceAll(value, "'", `'\''`))
+}
+
+// removeAliases removes the aliases, -a removes all of them.
+func (ia *Interactor) removeAliases(args []string) error {
+	if len(args) == 0 {
+		return parser.ErrInvalidUsage
+	}
+	ia.mu.Lock()
+	defer ia.mu.Unlock()
+	if len(args) == 1 && args[0] == "-a" {
+		clear(ia.aliases)
+		return nil
+	}
+	var errs []error
+	for _, name := range args {
+		if _, ok := ia.aliases[name]; !ok {
+			errs = append(errs, fmt.Errorf("unalias %s: not found", name))
+			continue
+		}
+		delete(ia.aliases, name)
+	}
+	return errors.Join(errs...)
+}
+
+// loadRC runs the rc file if it exists, reporting the failing lines.
+func (ia *Interactor) loadRC(ctx context.Context, file string) {
+	if file == "" {
+		return
+	}
+	if _, err := os.Stat(file); os.IsNotExist(err) {
+		return
+	}
+	if err := ia.runScript(ctx, file, nil, osStdio()); !errors.Is(err, os.ErrProcessDone) {
+		ia.report(err)
+	}
+}
@@ -3,18 +3,20 @@ package commands
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"strings"
+	"sync"
 
 	"github.com/cherrydra/mcpurl/interactor/commands/internal/ai"
+	"github.com/cherrydra/mcpurl/interactor/commands/internal/filter"
 	"github.com/cherrydra/mcpurl/interactor/commands/internal/system"
 	"github.com/cherrydra/mcpurl/interactor/commands/internal/types"
 	"github.com/cherrydra/mcpurl/llm"
 	"github.com/cherrydra/mcpurl/mcp/client"
 	"github.com/cherrydra/mcpurl/mcp/features"
-	"github.com/cherrydra/mcpurl/mcp/transport"
 	"github.com/cherrydra/mcpurl/parser"
 	"github.com/cherrydra/mcpurl/version"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
@@ -31,6 +33,7 @@ func init() {
 	registry["prompt"] = ai.GetPrompt
 	registry["P"] = ai.ListPrompts
 	registry["prompts"] = ai.ListPrompts
+	registry["refresh"] = ai.Refresh
 	registry["r"] = ai.ReadResource
 	registry["resource"] = ai.ReadResource
 	registry["R"] = ai.ListResources
@@ -40,6 +43,12 @@ func init() {
 	registry["T"] = ai.ListTools
 	registry["tools"] = ai.ListTools
 
+	registry["count"] = filter.Count
+	registry["grep"] = filter.Grep
+	registry["head"] = filter.Head
+	registry["json"] = filter.JSON
+	registry["table"] = filter.Table
+
 	registry["cat"] = system.ReadFile
 	registry["cd"] = system.Chdir
 	registry["clear"] = system.Clear
@@ -50,24 +59,32 @@ func init() {
 }
 
 type Commands struct {
-	Args    parser.Arguments
+	Args parser.Arguments
+	// Session is the current session.
 	Session *mcp.ClientSession
 	LLM     *llm.LLM
+	// Ask prompts the user for a line of input, nil if there is no one to ask.
+	Ask func(prompt string) (string, error)
 
-	connectedServer string
+	mu       sync.Mutex
+	sessions map[string]*serverSession
+	current  string
 }
 
-func (c *Commands) Exec(ctx context.Context, command string, args []string, in, out *os.File) error {
-	for i, arg := range args {
-		args[i] = os.ExpandEnv(arg)
-	}
+func (c *Commands) Exec(ctx context.Context, command string, args []string, in, out, errOut *os.File) error {
 	switch command {
 	case "c", "connect":
 		return c.connect(ctx, args, out)
 	case "disconnect":
-		return c.disconnect(ctx, out)
+		return c.disconnect(ctx, args, out)
+	case "use":
+		return c.use(ctx, args, out)
+	case "sessions":
+		return c.showSessions(ctx, out)
 	case "s", "status":
 		return c.showStatus(ctx, out)
+	case "info":
+		return c.showInfo(out)
 	case "q", "exit":
 		return os.ErrProcessDone
 	case "h", "help":
@@ -78,120 +95,202 @@ func (c *Commands) Exec(ctx context.Context, command string, args []string, in,
 	}
 
 	if cmd, ok := registry[command]; ok {
+		ask := c.Ask
+		background := ctx.Value(backgroundKey{}) != nil
+		if background {
+			ask = askInBackground
+		}
+		c.mu.Lock()
+		session := c.currentServerSession()
+		c.mu.Unlock()
+		switch command {
+		case "t", "tool", "p", "prompt":
+			// the tools and prompts of other sessions are qualified by the session name,
+			// resources are not as their uris have a scheme
+			if len(args) > 0 {
+				session, args[0] = c.qualifiedSession(args[0])
+			}
+		}
 		return cmd(ctx, types.Arguments{
 			LLM:      c.LLM,
-			Features: features.ServerFeatures{Session: c.Session, Out: out},
+			Server:   session.server,
+			Features: c.features(session.session, out, ask),
 			In:       in,
 			Out:      out,
+			Err:      errOut,
 			Args:     args,
+			Ask:      ask,
+
+			Background: background,
+
+			ArgumentsFile: c.Args.ArgumentsFile,
 		})
 	}
 
 	cmd := exec.CommandContext(ctx, command, args...)
 	cmd.Stdin = in
 	cmd.Stdout = out
-	cmd.Stderr = os.Stderr
+	cmd.Stderr = errOut
 	return cmd.Run()
 }
 
-func (c *Commands) Close() error {
-	if c.Session != nil {
-		c.Session.Close()
-		c.Session = nil
+type backgroundKey struct{}
+
+// Background returns the context of the commands run in the background, they cannot ask the user
+// as their input is not the terminal.
+func Background(ctx context.Context) context.Context {
+	return context.WithValue(ctx, backgroundKey{}, true)
+}
+
+func askInBackground(prompt string) (string, error) {
+	return "", types.ErrBackground
+}
+
+// Builtin reports whether the command is run by Commands rather than by an external program.
+func Builtin(command string) bool {
+	switch command {
+	case "c", "connect", "disconnect", "s", "status", "info", "q", "exit", "h", "help", "v", "version":
+		return true
 	}
-	return nil
+	_, ok := registry[command]
+	return ok
+}
+
+// Features returns the features of the current session writing to out.
+func (c *Commands) Features(out *os.File) features.ServerFeatures {
+	return c.features(c.currentSession(), out, c.Ask)
 }
 
-func (i *Commands) connect(ctx context.Context, args []string, out *os.File) error {
-	if len(args) == 0 {
-		return parser.ErrInvalidUsage
+func (c *Commands) features(session *mcp.ClientSession, out *os.File, ask func(string) (string, error)) features.ServerFeatures {
+	return features.ServerFeatures{
+		Session:    session,
+		Out:        out,
+		NoValidate: c.Args.NoValidate,
+		Confirm: func(prompt string) (bool, error) {
+			return c.confirm(prompt, ask)
+		},
 	}
+}
 
-	parsed := parser.Parser{}
-	if err := parsed.Parse(args); err != nil {
-		return fmt.Errorf("parse transport args: %w", err)
+func (c *Commands) confirm(prompt string, ask func(string) (string, error)) (bool, error) {
+	if c.Args.Yes {
+		return true, nil
 	}
-	parsedArgs := parsed.Arguments()
-	parsedArgs.Silent = true
-	clientTransport, err := transport.Transport(parsedArgs)
-	if err != nil {
-		return fmt.Errorf("transport: %w", err)
+	if ask == nil {
+		return false, nil
 	}
-	client := mcp.NewClient(client.Implementation, nil)
-	session, err := client.Connect(ctx, clientTransport)
+	answer, err := ask(prompt)
 	if err != nil {
-		return fmt.Errorf("connect mcp server: %w", err)
+		return false, err
 	}
-	if i.Session != nil {
-		json.NewEncoder(out).Encode(map[string]string{"msg": "disconnecting"})
-		i.Session.Close()
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
 	}
-	i.Session = session
-	i.connectedServer = strings.Join(parsedArgs.TransportArgs, " ")
-	return i.showStatus(ctx, out)
+	return false, nil
 }
 
-func (i *Commands) disconnect(ctx context.Context, out *os.File) error {
-	if i.Session == nil {
-		return nil
+func (c *Commands) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.adopt()
+	for name, s := range c.sessions {
+		client.Close(s.session)
+		delete(c.sessions, name)
 	}
-	json.NewEncoder(out).Encode(map[string]string{"msg": "disconnecting"})
-	i.Session.Close()
-	i.Session = nil
-	i.connectedServer = ""
-	return i.showStatus(ctx, out)
+	c.current, c.Session = "", nil
+	return nil
 }
 
-func (i *Commands) showStatus(ctx context.Context, out *os.File) error {
-	status := features.ErrNoSession.Error()
-	if i.Session != nil {
-		if sid := i.Session.ID(); sid != "" {
-			status = fmt.Sprintf("connected (%s)", sid)
-		} else {
-			status = "connected"
-		}
-		if err := i.Session.Ping(ctx, nil); err != nil {
-			status = "unhealth"
-		}
-		if i.connectedServer == "" {
-			i.connectedServer = strings.Join(i.Args.TransportArgs, " ")
-		}
+func (i *Commands) showInfo(out *os.File) error {
+	if i.Session == nil {
+		return features.ErrNoSession
 	}
-	json.NewEncoder(out).Encode(struct {
-		LLM    string `json:"llm,omitzero"`
-		Model  string `json:"model,omitzero"`
-		Server string `json:"server,omitzero"`
-		Status string `json:"status,omitzero"`
-	}{i.Args.LLMBaseURL, i.Args.LLMName, i.connectedServer, status})
-	return nil
+	init := client.Initialized(i.Session)
+	if init == nil || init.Result == nil {
+		return errors.New("no initialize result")
+	}
+	info := struct {
+		Server          *mcp.Implementation `json:"server"`
+		ProtocolVersion string              `json:"protocolVersion"`
+		Capabilities    any                 `json:"capabilities"`
+		Instructions    string              `json:"instructions,omitzero"`
+		Client          struct {
+			Implementation *mcp.Implementation     `json:"implementation"`
+			Capabilities   *mcp.ClientCapabilities `json:"capabilities,omitzero"`
+		} `json:"client"`
+	}{
+		Server:          init.Result.ServerInfo,
+		ProtocolVersion: init.Result.ProtocolVersion,
+		Capabilities:    init.Result.Capabilities,
+		Instructions:    init.Result.Instructions,
+	}
+	if init.Params != nil {
+		info.Client.Implementation = init.Params.ClientInfo
+		info.Client.Capabilities = init.Params.Capabilities
+	}
+	return json.NewEncoder(out).Encode(info)
 }
 
 func (c *Commands) PrintUsage() error {
 	fmt.Println(`Available Commands:
-  tools                           List tools
+  tools [-l]                      List tools
   prompts                         List prompts
   resources                       List resources
-  tool <name> [options]           Call tool
-  prompt <name> [options]         Get prompt
+  tool <name> [-i|-e] [options]   Call tool, -i asks for, -e edits the arguments
+  prompt <name> [-i] [options]    Get prompt, -i asks for the arguments
   resource <name>                 Read resource
   ctx <subcmd>                    LLM context operations
-  msg <message>                   Talk to LLM
-  connect <mcp_server> [options]  Connect to server
-  disconnect                      Disconnect from server
+  msg [message|-]                 Talk to LLM, - or no message reads stdin
+  connect [--name n] <mcp_server> Connect to server in a named session
+  disconnect [name]               Disconnect the session
+  use <name>                      Switch to the session
+  sessions                        List sessions
   status                          Show connection info
+  info                            Show server info and capabilities
+  refresh                         Reload cached server listings
+
+Tools and prompts of other sessions are addressed as <session>:<name>, resources are read from the current session.
+The arguments named like an option of tool or prompt (-i, -e, --yes, --no-validate) are given as key=value.
+An argument value - reads the input of the pipeline into the argument, @- reads the json payload.
+
+Filter Commands (read json lines from the pipe):
+  json [path]                     Select values by gjson path
+  table [columns ...]             Render values as a table
+  count                           Count lines
+  grep [-i] [-v] <pattern>        Print lines matching pattern
+  head [-n lines]                 Print the first lines
 
 System Commands:
-  cat <file>                      Read file
+  alias [name='command line']     Define or list aliases
+  cat [file]                      Read file, stdin without file
   cd [dir]                        Change working directory
   clear                           Clear the screen
   export [name=value ...]         Set/get environment variables
   exit                            Exit the interactor
+  fg [n]                          Show the output of the job and wait for it
   help                            Show this help message
+  history [-a] [--failed] [n]     List the command history of the server, !n and !! re-run
+  jobs                            List background jobs
+  kill <n>                        Cancel the background job
+  name=value                      Set a variable, PS1 is the prompt template
+  repeat <n> <command>            Run the command n times, counting the failures
+  set [name = <command>]          Capture the command output in a variable
+  source <file> [args]            Run commands from script file
+  time <command>                  Run the command, reporting its timing and traffic
+  unalias <name ...>              Remove aliases
+  unset <name ...>                Remove variables
   ls [dir]                        List files in directory
   pwd                             Print working directory
   version                         Show version information
+  wait [n]                        Wait for background jobs
+  watch [-n secs] [-d] <command>  Re-run the command, -d highlights the changes
 
-Supports command pipelining and stdout redirection:
-  tools | jq .name > tools.txt`)
+Supports quoting, pipelines, redirections (<, <<EOF, >, >>, 2>, 2>&1), command lists (;, &&, ||),
+background jobs (&), command substitution $(...) and variables, $_ is the previous result
+and ${name.path} extracts a field by gjson path. The PS1 placeholders are {server}, {session},
+{health}, {ctx}, {model}, {status} and {cwd}:
+  tools | json name > tools.txt && cat tools.txt
+  set files = tool list_files | json text ; tool read_file path=${files.0.name}`)
 	return nil
 }
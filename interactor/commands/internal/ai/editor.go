@@ -0,0 +1,317 @@
+package ai
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"github.com/cherrydra/mcpurl/interactor/commands/internal/types"
+	"github.com/cherrydra/mcpurl/mcp/features"
+	"github.com/modelcontextprotocol/go-sdk/jsonschema"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// editToolArguments opens the editor with the last arguments of the tool, or a skeleton of its input schema
+// filled with the arguments given on the command line, and returns the edited arguments.
+func editToolArguments(args types.Arguments, tool *mcp.Tool, arguments *schemaFlags, payload map[string]any) (map[string]any, error) {
+	if args.Background {
+		return nil, types.ErrBackground
+	}
+	name := args.Args[0]
+	params, err := arguments.merge(payload)
+	if err != nil {
+		return nil, err
+	}
+	doc, ok := "", false
+	if len(params) == 0 {
+		doc, ok = loadArguments(args.ArgumentsFile, args.Server, name)
+	}
+	if !ok {
+		doc = skeleton(name, tool, params)
+	}
+
+	file, err := os.CreateTemp("", "mcpurl-*.jsonc")
+	if err != nil {
+		return nil, fmt.Errorf("create arguments file: %w", err)
+	}
+	defer os.Remove(file.Name())
+	_, err = file.WriteString(doc)
+	file.Close()
+	if err != nil {
+		return nil, fmt.Errorf("write arguments file: %w", err)
+	}
+
+	for {
+		if err := runEditor(file.Name()); err != nil {
+			return nil, err
+		}
+		b, err := os.ReadFile(file.Name())
+		if err != nil {
+			return nil, fmt.Errorf("read arguments file: %w", err)
+		}
+		params, err = parseJSONC(b)
+		if err == nil {
+			if err := saveArguments(args.ArgumentsFile, args.Server, name, string(b)); err != nil {
+				fmt.Fprintf(args.Err, "Warning: %v\n", err)
+			}
+			return params, nil
+		}
+		if args.Ask == nil {
+			return nil, err
+		}
+		fmt.Fprintln(args.Err, err)
+		f := form{ask: args.Ask, out: args.Err}
+		if again, askErr := f.yes("Edit again?", true); askErr != nil || !again {
+			return nil, cmp.Or(askErr, err)
+		}
+	}
+}
+
+// runEditor edits the file with $VISUAL or $EDITOR on the terminal, vi by default.
+func runEditor(file string) error {
+	editor := strings.Fields(cmp.Or(os.Getenv("VISUAL"), os.Getenv("EDITOR"), "vi"))
+	cmd := exec.Command(editor[0], append(editor[1:], file)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("run editor: %w", err)
+	}
+	return nil
+}
+
+// skeleton returns a jsonc document of the tool arguments with the descriptions and choices as comments.
+// The required properties come first, the optional ones without a value are commented out.
+func skeleton(name string, tool *mcp.Tool, params map[string]any) string {
+	var lines []string
+	if tool != nil {
+		lines = append(lines, comment("", cmp.Or(tool.Description, name))...)
+		if hints := features.Hints(tool); len(hints) > 0 {
+			lines = append(lines, fmt.Sprintf("// Hints: %s", strings.Join(hints, ", ")))
+		}
+	}
+	var schema *jsonschema.Schema
+	if tool != nil {
+		schema = tool.InputSchema
+	}
+	lines = append(lines, skeletonObject("", schema, params)...)
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func skeletonObject(indent string, schema *jsonschema.Schema, params map[string]any) []string {
+	lines := []string{"{"}
+	var props []string
+	if schema != nil {
+		props = slices.Sorted(maps.Keys(schema.Properties))
+		slices.SortStableFunc(props, func(a, b string) int {
+			return cmp.Compare(boolInt(!slices.Contains(schema.Required, a)), boolInt(!slices.Contains(schema.Required, b)))
+		})
+	}
+	// the arguments given without being in the schema are kept too
+	for _, prop := range slices.Sorted(maps.Keys(params)) {
+		if !slices.Contains(props, prop) {
+			props = append(props, prop)
+		}
+	}
+	inner := indent + "  "
+	for _, prop := range props {
+		var propSchema *jsonschema.Schema
+		if schema != nil {
+			propSchema = schema.Properties[prop]
+		}
+		required := schema != nil && slices.Contains(schema.Required, prop)
+		value, exists := params[prop]
+
+		if propSchema != nil {
+			marker := "optional"
+			if required {
+				marker = "required"
+			}
+			text := fmt.Sprintf("(%s %s)", marker, schemaType(propSchema))
+			if desc := cmp.Or(propSchema.Description, propSchema.Title); desc != "" {
+				text = desc + " " + text
+			}
+			lines = append(lines, comment(inner, text)...)
+			if len(propSchema.Enum) > 0 {
+				lines = append(lines, inner+"// one of: "+encodeChoices(propSchema.Enum))
+			}
+		}
+
+		key, _ := json.Marshal(prop)
+		block := skeletonValue(inner, propSchema, value, exists)
+		block[0] = inner + string(key) + ": " + strings.TrimPrefix(block[0], inner)
+		block[len(block)-1] += ","
+		if !required && !exists {
+			for i, line := range block {
+				if !strings.HasPrefix(strings.TrimSpace(line), "//") {
+					block[i] = inner + "// " + strings.TrimPrefix(line, inner)
+				}
+			}
+		}
+		lines = append(lines, block...)
+	}
+	return append(lines, indent+"}")
+}
+
+// comment returns the lines of text as comments, so that multi-line descriptions stay valid jsonc.
+func comment(indent, text string) []string {
+	var lines []string
+	for line := range strings.SplitSeq(strings.TrimSpace(text), "\n") {
+		lines = append(lines, strings.TrimRight(indent+"// "+strings.TrimSpace(line), " "))
+	}
+	return lines
+}
+
+// skeletonValue returns the lines of the value, or of the default, first choice or zero value of the schema.
+func skeletonValue(indent string, schema *jsonschema.Schema, value any, exists bool) []string {
+	if !exists && schema != nil {
+		switch {
+		case len(schema.Default) > 0:
+			exists = json.Unmarshal(schema.Default, &value) == nil
+		case len(schema.Enum) > 0:
+			value, exists = schema.Enum[0], true
+		case schemaType(schema) == "object" && len(schema.Properties) > 0:
+			return skeletonObject(indent, schema, nil)
+		}
+	}
+	if !exists {
+		switch schemaType(schema) {
+		case "integer", "number":
+			value = 0
+		case "boolean":
+			value = false
+		case "array":
+			value = []any{}
+		case "object":
+			value = map[string]any{}
+		default:
+			value = ""
+		}
+	}
+	if nested, ok := value.(map[string]any); ok && schema != nil && len(schema.Properties) > 0 {
+		return skeletonObject(indent, schema, nested)
+	}
+	b, _ := json.MarshalIndent(value, indent, "  ")
+	return strings.Split(indent+string(b), "\n")
+}
+
+func encodeChoices(choices []any) string {
+	var ret []string
+	for _, c := range choices {
+		b, _ := json.Marshal(c)
+		ret = append(ret, string(b))
+	}
+	return strings.Join(ret, ", ")
+}
+
+// parseJSONC parses a json object with comments and trailing commas, an empty document cancels the call.
+func parseJSONC(b []byte) (map[string]any, error) {
+	b = stripJSONC(b)
+	if len(bytes.TrimSpace(b)) == 0 {
+		return nil, errCancelled
+	}
+	params := map[string]any{}
+	if err := json.Unmarshal(b, &params); err != nil {
+		return nil, fmt.Errorf("parse arguments: %w", err)
+	}
+	return params, nil
+}
+
+// stripJSONC removes the // and /* */ comments and the trailing commas outside the strings.
+func stripJSONC(src []byte) []byte {
+	var out []byte
+	inString := false
+	for i := 0; i < len(src); i++ {
+		c := src[i]
+		switch {
+		case inString:
+			out = append(out, c)
+			if c == '\\' && i+1 < len(src) {
+				i++
+				out = append(out, src[i])
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+			out = append(out, c)
+		case c == '/' && i+1 < len(src) && src[i+1] == '/':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+			out = append(out, '\n')
+		case c == '/' && i+1 < len(src) && src[i+1] == '*':
+			end := bytes.Index(src[i+2:], []byte("*/"))
+			if end < 0 {
+				return out
+			}
+			i += end + 3
+		case c == '}' || c == ']':
+			trimmed := bytes.TrimRight(out, " \t\r\n")
+			if len(trimmed) > 0 && trimmed[len(trimmed)-1] == ',' {
+				out = append(trimmed[:len(trimmed)-1], out[len(trimmed):]...)
+			}
+			out = append(out, c)
+		default:
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// loadArguments returns the last arguments document edited for the tool of the server.
+func loadArguments(file, server, tool string) (string, bool) {
+	if file == "" {
+		return "", false
+	}
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return "", false
+	}
+	docs := map[string]map[string]string{}
+	if err := json.Unmarshal(b, &docs); err != nil {
+		return "", false
+	}
+	doc, ok := docs[server][tool]
+	return doc, ok
+}
+
+// saveArguments keeps the arguments document edited for the tool of the server.
+// The documents are kept by server then by tool, a file in another format is kept as a .bak file and replaced.
+func saveArguments(file, server, tool, doc string) error {
+	if file == "" {
+		return nil
+	}
+	docs := map[string]map[string]string{}
+	b, err := os.ReadFile(file)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("read arguments file: %w", err)
+	}
+	if len(b) > 0 {
+		if err := json.Unmarshal(b, &docs); err != nil {
+			if err := os.Rename(file, file+".bak"); err != nil {
+				return fmt.Errorf("back up arguments file: %w", err)
+			}
+			docs = map[string]map[string]string{}
+		}
+	}
+	if docs[server] == nil {
+		docs[server] = map[string]string{}
+	}
+	docs[server][tool] = doc
+	b, err = json.MarshalIndent(docs, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal arguments: %w", err)
+	}
+	if err := os.WriteFile(file, b, 0o600); err != nil {
+		return fmt.Errorf("write arguments file: %w", err)
+	}
+	return nil
+}
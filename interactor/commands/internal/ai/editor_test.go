@@ -0,0 +1,154 @@
+package ai
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonschema"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestStripJSONC(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"plain", `{"a": 1}`, `{"a": 1}`},
+		{"line comment", "{\n// note\n\"a\": 1 // one\n}", "{\n\n\"a\": 1 \n}"},
+		{"block comment", `{/* a */"a": /* b
+			*/1}`, `{"a": 1}`},
+		{"unterminated block comment", `{"a": 1 /* b`, `{"a": 1 `},
+		{"trailing commas", `{"a": [1, 2,], "b": {"c": 3,},}`, `{"a": [1, 2], "b": {"c": 3}}`},
+		{"trailing comma before newline", "{\n  \"a\": 1,\n}", "{\n  \"a\": 1\n}"},
+		{"trailing comma before comment", "{\"a\": 1, // last\n}", "{\"a\": 1 \n}"},
+		{"slashes in strings", `{"url": "http://x/y", "c": "/* no */"}`, `{"url": "http://x/y", "c": "/* no */"}`},
+		{"escaped quote", `{"a": "x\"//y", "b": 1}`, `{"a": "x\"//y", "b": 1}`},
+		{"commas in strings", `{"a": ",}", "b": ",]"}`, `{"a": ",}", "b": ",]"}`},
+		{"empty", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(stripJSONC([]byte(tt.input))); got != tt.want {
+				t.Errorf("stripJSONC(%q)\n got: %q\nwant: %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseJSONC(t *testing.T) {
+	params, err := parseJSONC([]byte("{\n  // the text\n  \"text\": \"hi\",\n  // \"n\": 0,\n}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]any{"text": "hi"}; !reflect.DeepEqual(params, want) {
+		t.Errorf("parseJSONC = %v, want %v", params, want)
+	}
+	if _, err := parseJSONC([]byte("// nothing\n")); !errors.Is(err, errCancelled) {
+		t.Errorf("parseJSONC of an empty document error = %v, want %v", err, errCancelled)
+	}
+	if _, err := parseJSONC([]byte(`{"a": }`)); err == nil {
+		t.Error("parseJSONC of an invalid document succeeded")
+	}
+}
+
+func TestSkeleton(t *testing.T) {
+	var schema jsonschema.Schema
+	if err := json.Unmarshal([]byte(`{
+		"type": "object",
+		"required": ["text"],
+		"properties": {
+			"text": {"type": "string", "description": "the text\nto echo"},
+			"count": {"type": "integer", "default": 1},
+			"mode": {"type": "string", "enum": ["a", "b"]},
+			"tags": {"type": "array", "items": {"type": "string"}},
+			"filter": {"type": "object", "properties": {"owner": {"type": "string"}}}
+		}
+	}`), &schema); err != nil {
+		t.Fatal(err)
+	}
+	tool := &mcp.Tool{Name: "echo", Description: "Echo\n  the arguments\n", InputSchema: &schema}
+	tests := []struct {
+		name   string
+		params map[string]any
+		want   map[string]any
+	}{
+		{"empty", nil, map[string]any{"text": ""}},
+		{"given", map[string]any{"text": "hi", "count": 2, "mode": "b"}, map[string]any{"text": "hi", "count": 2.0, "mode": "b"}},
+		{"nested", map[string]any{"filter": map[string]any{"owner": "me"}}, map[string]any{"text": "", "filter": map[string]any{"owner": "me"}}},
+		{"not in schema", map[string]any{"other": []any{1.0}}, map[string]any{"text": "", "other": []any{1.0}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := skeleton("echo", tool, tt.params)
+			if !strings.HasPrefix(doc, "// Echo\n// the arguments\n") {
+				t.Errorf("skeleton does not start with the description as comments:\n%s", doc)
+			}
+			got, err := parseJSONC([]byte(doc))
+			if err != nil {
+				t.Fatalf("parseJSONC(skeleton) error: %v\n%s", err, doc)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseJSONC(skeleton) = %v, want %v\n%s", got, tt.want, doc)
+			}
+		})
+	}
+}
+
+func TestSkeletonComments(t *testing.T) {
+	var schema jsonschema.Schema
+	if err := json.Unmarshal([]byte(`{
+		"type": "object",
+		"properties": {"mode": {"type": "string", "description": "the mode", "enum": ["a", "b"]}}
+	}`), &schema); err != nil {
+		t.Fatal(err)
+	}
+	doc := skeleton("t", &mcp.Tool{Name: "t", InputSchema: &schema}, nil)
+	for _, want := range []string{
+		"// t\n",
+		"  // the mode (optional string)\n",
+		`  // one of: "a", "b"` + "\n",
+		`  // "mode": "a",` + "\n",
+	} {
+		if !strings.Contains(doc, want) {
+			t.Errorf("skeleton does not contain %q:\n%s", want, doc)
+		}
+	}
+}
+
+func TestArgumentsFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "arguments.json")
+	if _, ok := loadArguments(file, "s", "t"); ok {
+		t.Error("loadArguments found arguments in a missing file")
+	}
+	if err := saveArguments(file, "s", "t", "{}"); err != nil {
+		t.Fatal(err)
+	}
+	if err := saveArguments(file, "other", "t", `{"a": 1}`); err != nil {
+		t.Fatal(err)
+	}
+	if doc, ok := loadArguments(file, "s", "t"); !ok || doc != "{}" {
+		t.Errorf("loadArguments(s, t) = %q, %v, want %q", doc, ok, "{}")
+	}
+	if _, ok := loadArguments(file, "s", "other"); ok {
+		t.Error("loadArguments found the arguments of another tool")
+	}
+
+	if err := os.WriteFile(file, []byte("not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := saveArguments(file, "s", "t", "{}"); err != nil {
+		t.Fatal(err)
+	}
+	if b, err := os.ReadFile(file + ".bak"); err != nil || string(b) != "not json" {
+		t.Errorf("backup = %q, %v, want %q", b, err, "not json")
+	}
+	if doc, ok := loadArguments(file, "s", "t"); !ok || doc != "{}" {
+		t.Errorf("loadArguments after the backup = %q, %v, want %q", doc, ok, "{}")
+	}
+}
@@ -0,0 +1,215 @@
+package ai
+
+import (
+	"cmp"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonschema"
+)
+
+// schemaFlags registers one flag per schema property and collects the typed values.
+// Nested object properties are addressable with dotted keys, e.g. --filter.owner=me,
+// and the value - is read from stdin.
+type schemaFlags struct {
+	schema *jsonschema.Schema
+	params map[string]any
+	stdin  func() (string, error)
+}
+
+func newSchemaFlags(flags *flag.FlagSet, schema *jsonschema.Schema, stdin func() (string, error)) *schemaFlags {
+	f := &schemaFlags{schema: schema, params: map[string]any{}, stdin: stdin}
+	f.register(flags, "", schema)
+	return f
+}
+
+func (f *schemaFlags) register(flags *flag.FlagSet, prefix string, schema *jsonschema.Schema) {
+	if schema == nil {
+		return
+	}
+	for _, prop := range slices.Sorted(maps.Keys(schema.Properties)) {
+		v := schema.Properties[prop]
+		if v == nil {
+			continue
+		}
+		name := prefix + prop
+		required := "optional"
+		if slices.Contains(schema.Required, prop) {
+			required = "required"
+		}
+		usage := strings.TrimSpace(fmt.Sprintf("%s (%s `%s`)", cmp.Or(v.Description, v.Title), required, schemaType(v)))
+		if usableFlag(flags, name) {
+			flags.Var(&schemaValue{name: name, schema: v, params: f.params, stdin: f.stdin}, name, usage)
+		}
+		if schemaType(v) == "object" {
+			f.register(flags, name+".", v)
+		}
+	}
+}
+
+// usableFlag reports whether name can be registered as a flag, it must not be taken by an option
+// or another property and must parse as -name=value. The other properties are given as key=value.
+func usableFlag(flags *flag.FlagSet, name string) bool {
+	return name != "" && !strings.HasPrefix(name, "-") && !strings.Contains(name, "=") && flags.Lookup(name) == nil
+}
+
+// Params merges the collected arguments over base and checks the required properties.
+func (f *schemaFlags) Params(base map[string]any) (map[string]any, error) {
+	base, err := f.merge(base)
+	if err != nil {
+		return nil, err
+	}
+	if missing := missingRequired("", f.schema, base); len(missing) > 0 {
+		return nil, fmt.Errorf("missing required argument(s): --%s", strings.Join(missing, ", --"))
+	}
+	return base, nil
+}
+
+// merge merges the collected arguments over base.
+func (f *schemaFlags) merge(base map[string]any) (map[string]any, error) {
+	if base == nil {
+		base = map[string]any{}
+	}
+	for k, v := range f.params {
+		if err := setParam(base, k, v); err != nil {
+			return nil, err
+		}
+	}
+	return base, nil
+}
+
+func missingRequired(prefix string, schema *jsonschema.Schema, params map[string]any) (missing []string) {
+	if schema == nil {
+		return
+	}
+	for _, prop := range schema.Required {
+		if _, ok := params[prop]; !ok {
+			missing = append(missing, prefix+prop)
+		}
+	}
+	for _, prop := range slices.Sorted(maps.Keys(params)) {
+		if nested, ok := params[prop].(map[string]any); ok {
+			missing = append(missing, missingRequired(prefix+prop+".", schema.Properties[prop], nested)...)
+		}
+	}
+	return
+}
+
+type schemaValue struct {
+	name   string
+	schema *jsonschema.Schema
+	params map[string]any
+	stdin  func() (string, error)
+}
+
+func (v *schemaValue) String() string {
+	return ""
+}
+
+func (v *schemaValue) IsBoolFlag() bool {
+	return v.schema != nil && schemaType(v.schema) == "boolean"
+}
+
+func (v *schemaValue) Set(s string) error {
+	if s == "-" && v.stdin != nil {
+		in, err := v.stdin()
+		if err != nil {
+			return err
+		}
+		s = in
+		if schemaType(v.schema) != "string" {
+			s = strings.TrimSpace(s)
+		}
+	}
+	if schemaType(v.schema) != "array" || strings.HasPrefix(strings.TrimSpace(s), "[") {
+		value, err := parseSchemaValue(v.schema, s)
+		if err != nil {
+			return err
+		}
+		return setParam(v.params, v.name, value)
+	}
+	// repeatable array flag: --tags a --tags b
+	item, err := parseSchemaValue(v.schema.Items, s)
+	if err != nil {
+		return err
+	}
+	items, _ := getParam(v.params, v.name).([]any)
+	return setParam(v.params, v.name, append(items, item))
+}
+
+func parseSchemaValue(schema *jsonschema.Schema, s string) (any, error) {
+	switch schemaType(schema) {
+	case "integer":
+		return strconv.ParseInt(s, 10, 64)
+	case "number":
+		return strconv.ParseFloat(s, 64)
+	case "boolean":
+		return strconv.ParseBool(s)
+	case "array", "object":
+		var value any
+		if err := json.Unmarshal([]byte(s), &value); err != nil {
+			return nil, fmt.Errorf("parse json: %w", err)
+		}
+		return value, nil
+	default:
+		return s, nil
+	}
+}
+
+// schemaType returns the first non-null type of the schema, defaults to string.
+func schemaType(schema *jsonschema.Schema) string {
+	if schema == nil {
+		return "string"
+	}
+	if schema.Type != "" {
+		return schema.Type
+	}
+	for _, t := range schema.Types {
+		if t != "null" {
+			return t
+		}
+	}
+	return "string"
+}
+
+func getParam(params map[string]any, path string) any {
+	keys := strings.Split(path, ".")
+	for _, key := range keys[:len(keys)-1] {
+		nested, ok := params[key].(map[string]any)
+		if !ok {
+			return nil
+		}
+		params = nested
+	}
+	return params[keys[len(keys)-1]]
+}
+
+// setParam sets a dotted path in params, objects given as json are merged with dotted keys.
+func setParam(params map[string]any, path string, value any) error {
+	keys := strings.Split(path, ".")
+	for i, key := range keys[:len(keys)-1] {
+		nested, ok := params[key].(map[string]any)
+		if !ok {
+			if _, exists := params[key]; exists {
+				return fmt.Errorf("%s is not an object", strings.Join(keys[:i+1], "."))
+			}
+			nested = map[string]any{}
+			params[key] = nested
+		}
+		params = nested
+	}
+	last := keys[len(keys)-1]
+	if obj, ok := value.(map[string]any); ok {
+		if existing, ok := params[last].(map[string]any); ok {
+			maps.Copy(existing, obj)
+			return nil
+		}
+	}
+	params[last] = value
+	return nil
+}
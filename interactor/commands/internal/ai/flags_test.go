@@ -0,0 +1,168 @@
+package ai
+
+import (
+	"encoding/json"
+	"flag"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonschema"
+)
+
+const testSchema = `{
+	"type": "object",
+	"required": ["text"],
+	"properties": {
+		"text": {"type": "string"},
+		"count": {"type": "integer"},
+		"ratio": {"type": "number"},
+		"loud": {"type": "boolean"},
+		"note": {"type": ["null", "string"]},
+		"tags": {"type": "array", "items": {"type": "string"}},
+		"ids": {"type": "array", "items": {"type": "integer"}},
+		"filter": {
+			"type": "object",
+			"required": ["owner"],
+			"properties": {"owner": {"type": "string"}, "limit": {"type": "integer"}}
+		},
+		"yes": {"type": "boolean"},
+		"-x": {"type": "string"},
+		"k=v": {"type": "string"}
+	}
+}`
+
+// newTestFlags returns the flags of the test schema with the --yes option registered first, as CallTool does.
+func newTestFlags(t *testing.T, stdin string) (*flag.FlagSet, *schemaFlags, *bool) {
+	t.Helper()
+	var schema jsonschema.Schema
+	if err := json.Unmarshal([]byte(testSchema), &schema); err != nil {
+		t.Fatal(err)
+	}
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	yes := flags.Bool("yes", false, "")
+	read := false
+	arguments := newSchemaFlags(flags, &schema, func() (string, error) {
+		if read {
+			t.Fatal("stdin read twice")
+		}
+		read = true
+		return stdin, nil
+	})
+	return flags, arguments, yes
+}
+
+func TestSchemaFlags(t *testing.T) {
+	tests := []struct {
+		name  string
+		args  []string
+		stdin string
+		base  map[string]any
+		want  map[string]any
+	}{
+		{"string", []string{"--text", "hi"}, "", nil, map[string]any{"text": "hi"}},
+		{"typed", []string{"--text=a", "--count=2", "--ratio", "0.5", "--loud"}, "", nil,
+			map[string]any{"text": "a", "count": int64(2), "ratio": 0.5, "loud": true}},
+		{"false boolean", []string{"--text=a", "--loud=false"}, "", nil, map[string]any{"text": "a", "loud": false}},
+		{"nullable type", []string{"--text=a", "--note=n"}, "", nil, map[string]any{"text": "a", "note": "n"}},
+		{"repeated array", []string{"--text=a", "--tags", "x", "--tags", "y", "--ids", "1", "--ids", "2"}, "", nil,
+			map[string]any{"text": "a", "tags": []any{"x", "y"}, "ids": []any{int64(1), int64(2)}}},
+		{"json array", []string{"--text=a", `--tags=["x","y"]`}, "", nil, map[string]any{"text": "a", "tags": []any{"x", "y"}}},
+		{"dotted", []string{"--text=a", "--filter.owner=me", "--filter.limit=5"}, "", nil,
+			map[string]any{"text": "a", "filter": map[string]any{"owner": "me", "limit": int64(5)}}},
+		{"json object", []string{"--text=a", `--filter={"owner":"me"}`, "--filter.limit=5"}, "", nil,
+			map[string]any{"text": "a", "filter": map[string]any{"owner": "me", "limit": int64(5)}}},
+		{"stdin", []string{"--text", "-"}, "line\n", nil, map[string]any{"text": "line\n"}},
+		{"trimmed stdin", []string{"--text=a", "--count", "-"}, " 3\n", nil, map[string]any{"text": "a", "count": int64(3)}},
+		{"over base", []string{"--count=2"}, "", map[string]any{"text": "b", "count": 1.0, "filter": map[string]any{"owner": "you"}},
+			map[string]any{"text": "b", "count": int64(2), "filter": map[string]any{"owner": "you"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags, arguments, _ := newTestFlags(t, tt.stdin)
+			if err := flags.Parse(tt.args); err != nil {
+				t.Fatalf("Parse(%q) error: %v", tt.args, err)
+			}
+			got, err := arguments.Params(tt.base)
+			if err != nil {
+				t.Fatalf("Params error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Params(%q) = %v, want %v", tt.args, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSchemaFlagsError(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		err  string
+	}{
+		{"missing required", []string{"--count=1"}, "missing required argument(s): --text"},
+		{"missing nested required", []string{"--text=a", "--filter.limit=1"}, "missing required argument(s): --filter.owner"},
+		{"integer", []string{"--text=a", "--count=x"}, "invalid value"},
+		{"boolean", []string{"--text=a", "--loud=maybe"}, "invalid boolean value"},
+		{"json", []string{"--text=a", "--filter={"}, "parse json"},
+		{"unknown", []string{"--other=1"}, "flag provided but not defined"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags, arguments, _ := newTestFlags(t, "")
+			err := flags.Parse(tt.args)
+			if err == nil {
+				_, err = arguments.Params(nil)
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("Parse(%q) error = %v, want %q", tt.args, err, tt.err)
+			}
+		})
+	}
+}
+
+func TestSchemaFlagsCollisions(t *testing.T) {
+	flags, arguments, yes := newTestFlags(t, "")
+	if err := flags.Parse([]string{"--text=a", "--yes"}); err != nil {
+		t.Fatal(err)
+	}
+	if !*yes {
+		t.Error("--yes does not set the option registered first")
+	}
+	params, err := arguments.Params(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := params["yes"]; ok {
+		t.Error("--yes sets the property named like the option")
+	}
+	for _, name := range []string{"-x", "k=v"} {
+		if flags.Lookup(name) != nil {
+			t.Errorf("property %q is registered as a flag", name)
+		}
+	}
+}
+
+func TestUsableFlag(t *testing.T) {
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.Bool("i", false, "")
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"text", true},
+		{"filter.owner", true},
+		{"", false},
+		{"i", false},
+		{"-x", false},
+		{"--x", false},
+		{"k=v", false},
+	}
+	for _, tt := range tests {
+		if got := usableFlag(flags, tt.name); got != tt.want {
+			t.Errorf("usableFlag(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
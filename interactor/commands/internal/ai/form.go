@@ -0,0 +1,286 @@
+package ai
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/cherrydra/mcpurl/mcp/features"
+	"github.com/modelcontextprotocol/go-sdk/jsonschema"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+var (
+	errNoTerminal = errors.New("interactive arguments need a terminal")
+	errCancelled  = errors.New("cancelled")
+)
+
+// form asks for the arguments field by field, the hints are written to out.
+type form struct {
+	ask func(prompt string) (string, error)
+	out io.Writer
+}
+
+func newForm(ask func(prompt string) (string, error), out io.Writer) (*form, error) {
+	if ask == nil {
+		return nil, errNoTerminal
+	}
+	return &form{ask: ask, out: out}, nil
+}
+
+func (f *form) line(prompt string) (string, error) {
+	answer, err := f.ask(prompt)
+	if err != nil {
+		return "", fmt.Errorf("read answer: %w", err)
+	}
+	return strings.TrimSpace(answer), nil
+}
+
+// yes asks a yes or no question, an empty answer is def.
+func (f *form) yes(question string, def bool) (bool, error) {
+	choices := "[y/N]"
+	if def {
+		choices = "[Y/n]"
+	}
+	answer, err := f.line(fmt.Sprintf("%s %s ", question, choices))
+	if err != nil {
+		return false, err
+	}
+	switch strings.ToLower(answer) {
+	case "":
+		return def, nil
+	case "y", "yes":
+		return true, nil
+	}
+	return false, nil
+}
+
+// confirm shows the final arguments and asks whether to go on with them.
+func (f *form) confirm(action string, params any) error {
+	b, _ := json.MarshalIndent(params, "", "  ")
+	fmt.Fprintln(f.out, string(b))
+	ok, err := f.yes(fmt.Sprintf("%s with these arguments?", action), true)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errCancelled
+	}
+	return nil
+}
+
+// object asks for the properties of the object schema, required properties first.
+// The values already in params are offered as defaults.
+func (f *form) object(prefix string, schema *jsonschema.Schema, params map[string]any) error {
+	if schema == nil {
+		return nil
+	}
+	props := slices.Sorted(maps.Keys(schema.Properties))
+	slices.SortStableFunc(props, func(a, b string) int {
+		return cmp.Compare(boolInt(!slices.Contains(schema.Required, a)), boolInt(!slices.Contains(schema.Required, b)))
+	})
+	for _, prop := range props {
+		if schema.Properties[prop] == nil {
+			continue
+		}
+		current, exists := params[prop]
+		value, ok, err := f.field(prefix+prop, schema.Properties[prop], slices.Contains(schema.Required, prop), current, exists)
+		if err != nil {
+			return err
+		}
+		if ok {
+			params[prop] = value
+		} else {
+			delete(params, prop)
+		}
+	}
+	return nil
+}
+
+// field asks for the value of a property, ok is false if an optional property is left out.
+func (f *form) field(name string, schema *jsonschema.Schema, required bool, current any, exists bool) (value any, ok bool, err error) {
+	f.describe(name, schema, required)
+	switch t := schemaType(schema); {
+	case t == "object" && len(schema.Properties) > 0:
+		nested, _ := current.(map[string]any)
+		if nested == nil {
+			if !required {
+				if fill, err := f.yes(fmt.Sprintf("Fill in %s?", name), exists); err != nil || !fill {
+					return nil, false, err
+				}
+			}
+			nested = map[string]any{}
+		}
+		if err := f.object(name+".", schema, nested); err != nil {
+			return nil, false, err
+		}
+		return nested, true, nil
+	case t == "array" && !exists:
+		return f.array(name, schema, required)
+	}
+	return f.value(name, schema, required, current, exists)
+}
+
+// describe prints the type, required marker, description and choices of the property.
+func (f *form) describe(name string, schema *jsonschema.Schema, required bool) {
+	marker := "optional"
+	if required {
+		marker = "required"
+	}
+	fmt.Fprintf(f.out, "\033[1m%s\033[0m (%s, %s)", name, schemaType(schema), marker)
+	if desc := cmp.Or(schema.Description, schema.Title); desc != "" {
+		fmt.Fprintf(f.out, ": %s", desc)
+	}
+	fmt.Fprintln(f.out)
+	if len(schema.Enum) > 0 {
+		fmt.Fprintf(f.out, "  one of: %s\n", encodeChoices(schema.Enum))
+	}
+}
+
+// value asks for a scalar value, or a json document for the arrays and objects without properties,
+// until it is valid against the schema.
+func (f *form) value(name string, schema *jsonschema.Schema, required bool, current any, exists bool) (any, bool, error) {
+	def, hasDef := current, exists
+	if s, ok := current.(string); ok && schemaType(schema) != "string" {
+		// key=value items are strings, key:=json is needed for the other types on the command line
+		if v, err := parseAnswer(schema, s); err == nil {
+			def = v
+		}
+	}
+	if !hasDef && len(schema.Default) > 0 {
+		hasDef = json.Unmarshal(schema.Default, &def) == nil
+	}
+	prompt := name + ": "
+	if hasDef {
+		prompt = fmt.Sprintf("%s [%s]: ", name, display(def))
+	}
+	for {
+		answer, err := f.line(prompt)
+		if err != nil {
+			return nil, false, err
+		}
+		var value any
+		switch {
+		case answer != "":
+			if value, err = parseAnswer(schema, answer); err != nil {
+				err = fmt.Errorf("invalid %s: expected %s", name, schemaType(schema))
+			}
+		case hasDef:
+			value = def
+		case !required:
+			return nil, false, nil
+		default:
+			fmt.Fprintf(f.out, "%s is required\n", name)
+			continue
+		}
+		if err == nil {
+			err = features.Validate(name, schema, value)
+		}
+		if err != nil {
+			fmt.Fprintln(f.out, err)
+			continue
+		}
+		return value, true, nil
+	}
+}
+
+// array asks for the items one by one, an empty answer ends the array.
+func (f *form) array(name string, schema *jsonschema.Schema, required bool) (any, bool, error) {
+	items := schema.Items
+	if items == nil {
+		items = &jsonschema.Schema{}
+	}
+	for {
+		values := []any{}
+		for {
+			itemName := fmt.Sprintf("%s[%d]", name, len(values))
+			if schemaType(items) == "object" && len(items.Properties) > 0 {
+				more, err := f.yes(fmt.Sprintf("Add %s?", itemName), false)
+				if err != nil {
+					return nil, false, err
+				}
+				if !more {
+					break
+				}
+				item := map[string]any{}
+				if err := f.object(itemName+".", items, item); err != nil {
+					return nil, false, err
+				}
+				values = append(values, item)
+				continue
+			}
+			item, ok, err := f.value(itemName, items, false, nil, false)
+			if err != nil {
+				return nil, false, err
+			}
+			if !ok {
+				break
+			}
+			values = append(values, item)
+		}
+		if len(values) == 0 && !required {
+			return nil, false, nil
+		}
+		if err := features.Validate(name, schema, values); err != nil {
+			fmt.Fprintln(f.out, err)
+			continue
+		}
+		return values, true, nil
+	}
+}
+
+// prompt asks for the arguments of the prompt, required arguments first.
+func (f *form) prompt(arguments []*mcp.PromptArgument, params map[string]string) error {
+	arguments = slices.Clone(arguments)
+	slices.SortStableFunc(arguments, func(a, b *mcp.PromptArgument) int {
+		return cmp.Compare(boolInt(!a.Required), boolInt(!b.Required))
+	})
+	for _, arg := range arguments {
+		schema := &jsonschema.Schema{Type: "string", Title: arg.Title, Description: arg.Description}
+		current, exists := params[arg.Name]
+		f.describe(arg.Name, schema, arg.Required)
+		value, ok, err := f.value(arg.Name, schema, arg.Required, current, exists)
+		if err != nil {
+			return err
+		}
+		if ok {
+			params[arg.Name] = value.(string)
+		} else {
+			delete(params, arg.Name)
+		}
+	}
+	return nil
+}
+
+// parseAnswer parses the answer as a value of the schema type, booleans can be answered with y or n.
+func parseAnswer(schema *jsonschema.Schema, answer string) (any, error) {
+	if schemaType(schema) == "boolean" {
+		switch strings.ToLower(answer) {
+		case "y", "yes":
+			return true, nil
+		case "n", "no":
+			return false, nil
+		}
+	}
+	return parseSchemaValue(schema, answer)
+}
+
+func display(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
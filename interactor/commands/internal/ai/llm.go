@@ -10,16 +10,20 @@ import (
 	"github.com/cherrydra/mcpurl/interactor/commands/internal/types"
 	"github.com/cherrydra/mcpurl/llm"
 	"github.com/cherrydra/mcpurl/parser"
+	"github.com/mcpurl/readline"
 )
 
+// Msg talks to the LLM, the message is read from stdin if it is "-" or missing with stdin redirected.
 func Msg(ctx context.Context, args types.Arguments) error {
-	if len(args.Args) == 0 {
+	msg := "-"
+	if len(args.Args) > 0 {
+		msg = args.Args[0]
+	} else if readline.IsTerminal(int(args.In.Fd())) {
 		return parser.ErrInvalidUsage
 	}
 	if args.LLM == nil {
 		return llm.ErrDisabled
 	}
-	msg := args.Args[0]
 	if msg != "-" {
 		return args.LLM.Msg(ctx, args.Features, msg, args.Out)
 	}
@@ -3,33 +3,37 @@ package ai
 import (
 	"cmp"
 	"context"
+	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
-	"os"
-	"slices"
 	"strings"
 
 	"github.com/cherrydra/mcpurl/interactor/commands/internal/types"
+	"github.com/cherrydra/mcpurl/mcp/client"
+	"github.com/cherrydra/mcpurl/mcp/features"
 	"github.com/cherrydra/mcpurl/parser"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
+// CallTool calls the tool, an argument value - reads the input of the command and @- reads the payload from it.
 func CallTool(ctx context.Context, args types.Arguments) error {
 	if len(args.Args) == 0 {
 		return parser.ErrInvalidUsage
 	}
-	// tool <tool> @data.json
-	if len(args.Args) >= 2 && strings.HasPrefix(args.Args[1], "@") {
-		data, err := parser.Parser{}.ParseData(args.Args[1])
-		if err != nil {
-			return fmt.Errorf("parse tool arguments: %w", err)
-		}
-		return args.Features.CallTool(ctx, args.Args[0], data)
-	}
 
-	// tool <tool> [options]
+	// tool <tool> [@data.json|@-] [key=value key:=json ...] [options]
+	p := parser.Parser{Stdin: args.In}
 	flags := flag.NewFlagSet(args.Args[0], flag.ContinueOnError)
-	arguments := map[string]*string{}
+	flags.SetOutput(args.Err)
+	// the options are registered first, the properties named like them are given as key=value
+	var interactive, edit, noValidate, yes bool
+	flags.BoolVar(&interactive, "i", false, "Ask for the arguments one by one")
+	flags.BoolVar(&edit, "e", false, "Edit the arguments in $EDITOR, the last ones are reopened")
+	flags.BoolVar(&noValidate, "no-validate", false, "Skip validating arguments and results against the tool schemas")
+	flags.BoolVar(&yes, "yes", false, "Call the tool without confirmation even if it is destructive")
+	var arguments *schemaFlags
+	var found *mcp.Tool
 	tools, err := args.Features.ListTools(ctx)
 	if err != nil {
 		return fmt.Errorf("list tools: %w", err)
@@ -39,54 +43,82 @@ func CallTool(ctx context.Context, args types.Arguments) error {
 			continue
 		}
 		flags.Usage = func() {
-			fmt.Fprintf(os.Stderr, "Usage: %s [options]\n\n", tool.Name)
-			fmt.Fprintf(os.Stderr, "%s\n\n", tool.Description)
-			fmt.Fprintln(os.Stderr, "Options:")
-			flags.PrintDefaults()
-		}
-		for prop, v := range tool.InputSchema.Properties {
-			p := new(string)
-			arguments[prop] = p
-			if slices.Contains(tool.InputSchema.Required, prop) {
-				v.Description = fmt.Sprintf("%s (required)", cmp.Or(v.Description, v.Title))
-			} else {
-				v.Description = fmt.Sprintf("%s (optional)", cmp.Or(v.Description, v.Title))
+			fmt.Fprintf(args.Err, "Usage: %s [@data.json] [key=value key:=json ...] [options]\n\n", tool.Name)
+			fmt.Fprintf(args.Err, "%s\n\n", tool.Description)
+			if hints := features.Hints(tool); len(hints) > 0 {
+				fmt.Fprintf(args.Err, "Hints: %s\n\n", strings.Join(hints, ", "))
 			}
-			flags.StringVar(p, prop, "", v.Description)
+			fmt.Fprintln(args.Err, "Options:")
+			flags.PrintDefaults()
 		}
+		arguments = newSchemaFlags(flags, tool.InputSchema, p.ReadStdin)
+		found = tool
+	}
+	if arguments == nil {
+		arguments = newSchemaFlags(flags, nil, p.ReadStdin)
 	}
-	if err := flags.Parse(args.Args[1:]); err != nil {
+	payload, err := parseFlags(&p, flags, args.Args[1:])
+	if err != nil {
 		if errors.Is(err, flag.ErrHelp) {
 			return nil
 		}
-		return fmt.Errorf("parse flags: %w", err)
+		return err
 	}
-	params := map[string]any{}
-	for k, v := range arguments {
-		if *v != "" {
-			params[k] = v
-		}
+	var params map[string]any
+	switch {
+	case interactive && edit:
+		return fmt.Errorf("-i and -e cannot be used together")
+	case interactive:
+		params, err = askToolArguments(args, arguments, payload)
+	case edit:
+		params, err = editToolArguments(args, found, arguments, payload)
+	default:
+		params, err = arguments.Params(payload)
+	}
+	if err != nil {
+		return err
+	}
+	args.Features.NoValidate = args.Features.NoValidate || noValidate
+	if yes {
+		args.Features.Confirm = func(string) (bool, error) { return true, nil }
 	}
 	return args.Features.CallTool1(ctx, args.Args[0], params)
 }
 
+// askToolArguments asks for the tool arguments, the arguments given on the command line are the defaults.
+func askToolArguments(args types.Arguments, arguments *schemaFlags, payload map[string]any) (map[string]any, error) {
+	f, err := newForm(args.Ask, args.Err)
+	if err != nil {
+		return nil, err
+	}
+	params, err := arguments.merge(payload)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.object("", arguments.schema, params); err != nil {
+		return nil, err
+	}
+	if err := f.confirm(fmt.Sprintf("Call tool %s", args.Args[0]), params); err != nil {
+		return nil, err
+	}
+	return params, nil
+}
+
+// GetPrompt gets the prompt, an argument value - reads the input of the command and @- reads the payload from it.
 func GetPrompt(ctx context.Context, args types.Arguments) error {
 	if len(args.Args) == 0 {
 		return parser.ErrInvalidUsage
 	}
 
-	// prompt <prompt> @data.json
-	if len(args.Args) >= 2 && strings.HasPrefix(args.Args[1], "@") {
-		data, err := parser.Parser{}.ParseData(args.Args[1])
-		if err != nil {
-			return fmt.Errorf("parse prompt arguments: %w", err)
-		}
-		return args.Features.GetPrompt(ctx, args.Args[0], data)
-	}
-
-	// prompt <prompt> [options]
+	// prompt <prompt> [@data.json|@-] [key=value ...] [options]
+	p := parser.Parser{Stdin: args.In}
 	flags := flag.NewFlagSet(args.Args[0], flag.ContinueOnError)
+	flags.SetOutput(args.Err)
+	// the option is registered first, the arguments named like it are given as key=value
+	var interactive bool
+	flags.BoolVar(&interactive, "i", false, "Ask for the arguments one by one")
 	arguments := map[string]*string{}
+	var promptArguments []*mcp.PromptArgument
 
 	prompts, err := args.Features.ListPrompts(ctx)
 	if err != nil {
@@ -97,37 +129,115 @@ func GetPrompt(ctx context.Context, args types.Arguments) error {
 			continue
 		}
 		flags.Usage = func() {
-			fmt.Fprintf(os.Stderr, "Usage: %s [options]\n\n", prompt.Name)
-			fmt.Fprintf(os.Stderr, "%s\n\n", prompt.Description)
-			fmt.Fprintln(os.Stderr, "Options:")
+			fmt.Fprintf(args.Err, "Usage: %s [@data.json] [key=value ...] [options]\n\n", prompt.Name)
+			fmt.Fprintf(args.Err, "%s\n\n", prompt.Description)
+			fmt.Fprintln(args.Err, "Options:")
 			flags.PrintDefaults()
 		}
+		promptArguments = prompt.Arguments
 		for _, prop := range prompt.Arguments {
+			if !usableFlag(flags, prop.Name) {
+				continue
+			}
 			p := new(string)
 			arguments[prop.Name] = p
+			usage := fmt.Sprintf("%s (optional)", prop.Description)
 			if prop.Required {
-				prop.Description = fmt.Sprintf("%s (required)", prop.Description)
-			} else {
-				prop.Description = fmt.Sprintf("%s (optional)", prop.Description)
+				usage = fmt.Sprintf("%s (required)", prop.Description)
 			}
-			flags.StringVar(p, prop.Name, "", prop.Description)
+			flags.StringVar(p, prop.Name, "", usage)
 		}
 	}
-	if err := flags.Parse(args.Args[1:]); err != nil {
+	payload, err := parseFlags(&p, flags, args.Args[1:])
+	if err != nil {
 		if errors.Is(err, flag.ErrHelp) {
 			return nil
 		}
-		return fmt.Errorf("parse flags: %w", err)
+		return err
 	}
 	params := map[string]string{}
+	for k, v := range payload {
+		if str, ok := v.(string); ok {
+			params[k] = str
+			continue
+		}
+		b, _ := json.Marshal(v)
+		params[k] = string(b)
+	}
 	for k, v := range arguments {
-		if v != nil {
+		switch *v {
+		case "":
+		case "-":
+			if params[k], err = p.ReadStdin(); err != nil {
+				return err
+			}
+		default:
 			params[k] = *v
 		}
 	}
+	if interactive {
+		f, err := newForm(args.Ask, args.Err)
+		if err != nil {
+			return err
+		}
+		if err := f.prompt(promptArguments, params); err != nil {
+			return err
+		}
+		if err := f.confirm(fmt.Sprintf("Get prompt %s", args.Args[0]), params); err != nil {
+			return err
+		}
+	}
 	return args.Features.GetPrompt1(ctx, args.Args[0], params)
 }
 
+// parseFlags parses the flags which may be interleaved with positional payload arguments,
+// the payload is a @data.json file, @- for stdin, an inline json object or request items merged together.
+func parseFlags(p *parser.Parser, flags *flag.FlagSet, args []string) (map[string]any, error) {
+	var positional []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return nil, err
+			}
+			return nil, fmt.Errorf("parse flags: %w", err)
+		}
+		args = flags.Args()
+		for len(args) > 0 && (!strings.HasPrefix(args[0], "-") || args[0] == "-") {
+			positional = append(positional, args[0])
+			args = args[1:]
+		}
+		if len(args) == 0 {
+			break
+		}
+	}
+
+	var data string
+	var items []string
+	for _, arg := range positional {
+		switch {
+		case p.IsRequestItem(arg):
+			items = append(items, arg)
+		case strings.HasPrefix(arg, "@"), strings.HasPrefix(arg, "{"):
+			d, err := p.ParseData(arg)
+			if err != nil {
+				return nil, fmt.Errorf("parse data: %w", err)
+			}
+			data = d
+		default:
+			return nil, fmt.Errorf("unexpected argument: %s", arg)
+		}
+	}
+	data, err := p.ParseRequestItems(data, items)
+	if err != nil {
+		return nil, fmt.Errorf("parse request items: %w", err)
+	}
+	payload := map[string]any{}
+	if err := json.Unmarshal([]byte(data), &payload); err != nil {
+		return nil, fmt.Errorf("unmarshal data: %w", err)
+	}
+	return payload, nil
+}
+
 func ReadResource(ctx context.Context, args types.Arguments) error {
 	if len(args.Args) == 0 {
 		return parser.ErrInvalidUsage
@@ -143,6 +253,62 @@ func ListResources(ctx context.Context, args types.Arguments) error {
 	return args.Features.PrintResources(ctx)
 }
 
+// ListTools prints the tools as json lines, or with -l one line per tool with its behavior hints.
 func ListTools(ctx context.Context, args types.Arguments) error {
-	return args.Features.PrintTools(ctx)
+	if len(args.Args) == 0 || args.Args[0] != "-l" {
+		return args.Features.PrintTools(ctx)
+	}
+	tools, err := args.Features.ListTools(ctx)
+	if err != nil {
+		return err
+	}
+	for _, tool := range tools {
+		hints := strings.Join(features.Hints(tool), ",")
+		fmt.Fprintf(args.Out, "%-32s %-40s %s\n", tool.Name, cmp.Or(hints, "-"), features.Title(tool))
+	}
+	return nil
+}
+
+// Refresh drops the cached server listings and loads them again, the listings the server
+// does not advertise in its capabilities are skipped and the failing ones are reported.
+func Refresh(ctx context.Context, args types.Arguments) error {
+	if args.Features.Session == nil {
+		return features.ErrNoSession
+	}
+	args.Features.Refresh()
+	// the listings are assumed advertised if the initialize handshake is unknown
+	tools, prompts, resources := true, true, true
+	if init := client.Initialized(args.Features.Session); init != nil && init.Result != nil && init.Result.Capabilities != nil {
+		caps := init.Result.Capabilities
+		tools, prompts, resources = caps.Tools != nil, caps.Prompts != nil, caps.Resources != nil
+	}
+	counts := map[string]int{}
+	var errs []error
+	count := func(name string, advertised bool, list func() (int, error)) {
+		if !advertised {
+			return
+		}
+		n, err := list()
+		if err != nil {
+			errs = append(errs, err)
+			return
+		}
+		counts[name] = n
+	}
+	count("tools", tools, func() (int, error) {
+		tools, err := args.Features.ListTools(ctx)
+		return len(tools), err
+	})
+	count("prompts", prompts, func() (int, error) {
+		prompts, err := args.Features.ListPrompts(ctx)
+		return len(prompts), err
+	})
+	count("resources", resources, func() (int, error) {
+		resources, err := args.Features.ListResources(ctx)
+		return len(resources), err
+	})
+	if err := json.NewEncoder(args.Out).Encode(counts); err != nil {
+		return err
+	}
+	return errors.Join(errs...)
 }
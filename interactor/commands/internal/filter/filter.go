@@ -0,0 +1,268 @@
+package filter
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/cherrydra/mcpurl/interactor/commands/internal/types"
+	"github.com/cherrydra/mcpurl/parser"
+	"github.com/mattn/go-runewidth"
+	"github.com/tidwall/gjson"
+)
+
+// maxCellWidth truncates the table cells so that a long description does not wrap every row.
+const maxCellWidth = 48
+
+// JSON prints the value at the gjson path of each json value read from the input, strings are printed unquoted.
+// Without a path the values are pretty printed, a path starting with ".." queries the whole input as json lines.
+func JSON(_ context.Context, args types.Arguments) error {
+	if len(args.Args) > 1 {
+		return parser.ErrInvalidUsage
+	}
+	path := ""
+	if len(args.Args) == 1 {
+		path = args.Args[0]
+	}
+	if strings.HasPrefix(path, "..") {
+		data, err := io.ReadAll(args.In)
+		if err != nil {
+			return fmt.Errorf("read input: %w", err)
+		}
+		printResult(args.Out, gjson.GetBytes(data, path))
+		return nil
+	}
+	return eachValue(args.In, func(value []byte) error {
+		if path == "" {
+			var buf bytes.Buffer
+			if err := json.Indent(&buf, value, "", "  "); err != nil {
+				return err
+			}
+			fmt.Fprintln(args.Out, buf.String())
+			return nil
+		}
+		printResult(args.Out, gjson.GetBytes(value, path))
+		return nil
+	})
+}
+
+// Table renders the json values read from the input as a table, columns are gjson paths
+// and default to the keys of the objects in the order they first appear.
+func Table(_ context.Context, args types.Arguments) error {
+	var rows []gjson.Result
+	if err := eachValue(args.In, func(value []byte) error {
+		rows = append(rows, gjson.ParseBytes(value))
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	columns := args.Args
+	if len(columns) == 0 {
+		seen := map[string]bool{}
+		for _, row := range rows {
+			if !row.IsObject() {
+				if !seen["@this"] {
+					seen["@this"] = true
+					columns = append(columns, "@this")
+				}
+				continue
+			}
+			row.ForEach(func(key, _ gjson.Result) bool {
+				if !seen[key.String()] {
+					seen[key.String()] = true
+					columns = append(columns, escapePath(key.String()))
+				}
+				return true
+			})
+		}
+	}
+	if len(columns) == 0 {
+		return nil
+	}
+
+	cells := make([][]string, 0, len(rows)+1)
+	header := make([]string, len(columns))
+	for i, column := range columns {
+		header[i] = strings.ToUpper(column)
+		if column == "@this" {
+			header[i] = "VALUE"
+		}
+	}
+	cells = append(cells, header)
+	for _, row := range rows {
+		line := make([]string, len(columns))
+		for i, column := range columns {
+			line[i] = cell(row.Get(column))
+		}
+		cells = append(cells, line)
+	}
+
+	widths := make([]int, len(columns))
+	for _, line := range cells {
+		for i, c := range line {
+			widths[i] = max(widths[i], runewidth.StringWidth(c))
+		}
+	}
+	for _, line := range cells {
+		for i, c := range line {
+			if i == len(line)-1 {
+				fmt.Fprintln(args.Out, c)
+				continue
+			}
+			fmt.Fprint(args.Out, runewidth.FillRight(c, widths[i]+2))
+		}
+	}
+	return nil
+}
+
+// Count prints the number of non-empty lines read from the input.
+func Count(_ context.Context, args types.Arguments) error {
+	n := 0
+	if err := eachLine(args.In, func(line string) bool {
+		if strings.TrimSpace(line) != "" {
+			n++
+		}
+		return true
+	}); err != nil {
+		return err
+	}
+	fmt.Fprintln(args.Out, n)
+	return nil
+}
+
+// Grep prints the lines read from the input matching the regular expression.
+func Grep(_ context.Context, args types.Arguments) error {
+	flags := flag.NewFlagSet("grep", flag.ContinueOnError)
+	flags.SetOutput(args.Err)
+	flags.Usage = func() {
+		fmt.Fprintln(args.Err, "Usage: grep [-i] [-v] <pattern>")
+		flags.PrintDefaults()
+	}
+	ignoreCase := flags.Bool("i", false, "Ignore case distinctions")
+	invert := flags.Bool("v", false, "Print the lines not matching")
+	if err := flags.Parse(args.Args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return fmt.Errorf("parse flags: %w", err)
+	}
+	if flags.NArg() != 1 {
+		return parser.ErrInvalidUsage
+	}
+	pattern := flags.Arg(0)
+	if *ignoreCase {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("compile pattern: %w", err)
+	}
+	return eachLine(args.In, func(line string) bool {
+		if re.MatchString(line) != *invert {
+			fmt.Fprintln(args.Out, line)
+		}
+		return true
+	})
+}
+
+// Head prints the first lines read from the input, 10 by default.
+func Head(_ context.Context, args types.Arguments) error {
+	flags := flag.NewFlagSet("head", flag.ContinueOnError)
+	flags.SetOutput(args.Err)
+	flags.Usage = func() {
+		fmt.Fprintln(args.Err, "Usage: head [-n lines]")
+		flags.PrintDefaults()
+	}
+	n := flags.Int("n", 10, "Number of lines to print")
+	if err := flags.Parse(args.Args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return fmt.Errorf("parse flags: %w", err)
+	}
+	if flags.NArg() > 0 {
+		return parser.ErrInvalidUsage
+	}
+	printed := 0
+	return eachLine(args.In, func(line string) bool {
+		if printed >= *n {
+			return false
+		}
+		fmt.Fprintln(args.Out, line)
+		printed++
+		return true
+	})
+}
+
+func printResult(out io.Writer, result gjson.Result) {
+	if !result.Exists() {
+		return
+	}
+	if result.Type == gjson.String {
+		fmt.Fprintln(out, result.String())
+		return
+	}
+	fmt.Fprintln(out, result.Raw)
+}
+
+func cell(result gjson.Result) string {
+	s := result.Raw
+	if result.Type == gjson.String {
+		s = result.String()
+	}
+	s = strings.Join(strings.Fields(s), " ")
+	return runewidth.Truncate(s, maxCellWidth, "…")
+}
+
+// escapePath escapes the gjson path syntax in an object key.
+func escapePath(key string) string {
+	var b strings.Builder
+	for _, r := range key {
+		if strings.ContainsRune(`\.*?|#@!=<>%,:()[]{}"`, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// eachValue calls fn with each json value read from in, values can span multiple lines.
+func eachValue(in io.Reader, fn func(value []byte) error) error {
+	decoder := json.NewDecoder(in)
+	for {
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("decode json: %w", err)
+		}
+		if err := fn(value); err != nil {
+			return err
+		}
+	}
+}
+
+// eachLine calls fn with each line read from in until fn returns false.
+// The rest of the input is then read and discarded, so that the command writing to the pipe ends normally.
+func eachLine(in io.Reader, fn func(line string) bool) error {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if !fn(scanner.Text()) {
+			_, _ = io.Copy(io.Discard, in)
+			return nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read input: %w", err)
+	}
+	return nil
+}
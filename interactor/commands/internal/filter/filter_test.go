@@ -0,0 +1,152 @@
+package filter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cherrydra/mcpurl/interactor/commands/internal/types"
+	"github.com/cherrydra/mcpurl/parser"
+)
+
+// run runs the filter with the input and returns its output.
+func run(t *testing.T, filter func(context.Context, types.Arguments) error, input string, args ...string) (string, error) {
+	t.Helper()
+	dir := t.TempDir()
+	in, err := os.Create(filepath.Join(dir, "in"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer in.Close()
+	if _, err := in.WriteString(input); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := in.Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	out, err := os.Create(filepath.Join(dir, "out"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	err = filter(context.Background(), types.Arguments{In: in, Out: out, Err: out, Args: args})
+	b, readErr := os.ReadFile(out.Name())
+	if readErr != nil {
+		t.Fatal(readErr)
+	}
+	return string(b), err
+}
+
+func TestFilters(t *testing.T) {
+	const lines = `{"name":"a","n":1}
+{"name":"b","n":2}
+
+{"name":"c","n":3,"tags":["x"]}
+`
+	tests := []struct {
+		name   string
+		filter func(context.Context, types.Arguments) error
+		args   []string
+		input  string
+		want   string
+	}{
+		{"json path", JSON, []string{"name"}, lines, "a\nb\nc\n"},
+		{"json raw value", JSON, []string{"tags"}, lines, `["x"]` + "\n"},
+		{"json pretty", JSON, nil, `{"a":[1]}`, "{\n  \"a\": [\n    1\n  ]\n}\n"},
+		{"json multi-line value", JSON, []string{"a"}, "{\n\"a\": 1\n}\n{\"a\": 2}", "1\n2\n"},
+		{"json whole input", JSON, []string{"..#.n"}, lines, "[1,2,3]\n"},
+		{"count", Count, nil, lines, "3\n"},
+		{"count empty", Count, nil, "", "0\n"},
+		{"grep", Grep, []string{`"[ab]"`}, lines, `{"name":"a","n":1}` + "\n" + `{"name":"b","n":2}` + "\n"},
+		{"grep ignore case", Grep, []string{"-i", "TAGS"}, lines, `{"name":"c","n":3,"tags":["x"]}` + "\n"},
+		{"grep invert", Grep, []string{"-v", "name"}, lines, "\n"},
+		{"head", Head, []string{"-n", "2"}, lines, `{"name":"a","n":1}` + "\n" + `{"name":"b","n":2}` + "\n"},
+		{"head default", Head, nil, strings.Repeat("x\n", 12), strings.Repeat("x\n", 10)},
+		{"head more than input", Head, []string{"-n", "5"}, "x\ny", "x\ny\n"},
+		{"head zero", Head, []string{"-n", "0"}, "x\n", ""},
+		{"table", Table, nil, `{"name":"a","n":1}{"name":"bb"}`, "NAME  N\na     1\nbb    \n"},
+		{"table columns", Table, []string{"n"}, `{"name":"a","n":1}`, "N\n1\n"},
+		{"table values", Table, nil, "1 \"x\"", "VALUE\n1\nx\n"},
+		{"table dotted key", Table, nil, `{"a.b":1}`, "A\\.B\n1\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := run(t, tt.filter, tt.input, tt.args...)
+			if err != nil {
+				t.Fatalf("error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got:\n%q\nwant:\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFiltersError(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter func(context.Context, types.Arguments) error
+		args   []string
+		input  string
+		err    error
+	}{
+		{"json two paths", JSON, []string{"a", "b"}, "", parser.ErrInvalidUsage},
+		{"json invalid input", JSON, []string{"a"}, "{", nil},
+		{"grep without pattern", Grep, nil, "", parser.ErrInvalidUsage},
+		{"grep invalid pattern", Grep, []string{"("}, "", nil},
+		{"head argument", Head, []string{"x"}, "", parser.ErrInvalidUsage},
+		{"head invalid count", Head, []string{"-n", "x"}, "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := run(t, tt.filter, tt.input, tt.args...)
+			if err == nil {
+				t.Fatal("succeeded, want an error")
+			}
+			if tt.err != nil && !errors.Is(err, tt.err) {
+				t.Errorf("error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+// TestHeadDrainsInput checks that the command writing to head through a pipe is not failed when head stops.
+func TestHeadDrainsInput(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	written := make(chan error, 1)
+	go func() {
+		defer w.Close()
+		// more than the buffer of the pipe
+		for i := range 100000 {
+			if _, err := fmt.Fprintf(w, "line %d\n", i); err != nil {
+				written <- err
+				return
+			}
+		}
+		written <- nil
+	}()
+	out, err := os.Create(filepath.Join(t.TempDir(), "out"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	err = Head(context.Background(), types.Arguments{In: r, Out: out, Err: out, Args: []string{"-n", "1"}})
+	r.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := <-written; err != nil {
+		t.Errorf("write to head: %v", err)
+	}
+	if b, _ := os.ReadFile(out.Name()); string(b) != "line 0\n" {
+		t.Errorf("head output = %q, want %q", b, "line 0\n")
+	}
+}
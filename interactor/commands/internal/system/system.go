@@ -10,6 +10,7 @@ import (
 	"github.com/cherrydra/mcpurl/interactor/commands/internal/types"
 	"github.com/cherrydra/mcpurl/llm"
 	"github.com/cherrydra/mcpurl/parser"
+	"github.com/mcpurl/readline"
 )
 
 func Chdir(_ context.Context, args types.Arguments) error {
@@ -78,18 +79,23 @@ func PrintPwd(_ context.Context, args types.Arguments) error {
 	return nil
 }
 
+// ReadFile prints the file, stdin if the file is missing and stdin is redirected.
 func ReadFile(_ context.Context, args types.Arguments) error {
-	if len(args.Args) < 1 {
+	name, file := "stdin", args.In
+	if len(args.Args) == 0 && readline.IsTerminal(int(args.In.Fd())) {
 		return parser.ErrInvalidUsage
 	}
-	file, err := os.Open(args.Args[0])
-	if err != nil {
-		return fmt.Errorf("open file %s: %w", args.Args[0], err)
+	if len(args.Args) > 0 {
+		f, err := os.Open(args.Args[0])
+		if err != nil {
+			return fmt.Errorf("open file %s: %w", args.Args[0], err)
+		}
+		defer f.Close()
+		name, file = args.Args[0], f
 	}
-	defer file.Close()
 	detector := &llm.LastByteDetector{}
 	if _, err := io.Copy(io.MultiWriter(args.Out, detector), file); err != nil {
-		return fmt.Errorf("read file %s: %w", args.Args[0], err)
+		return fmt.Errorf("read file %s: %w", name, err)
 	}
 	if detector.LastByte() != '\n' {
 		fmt.Fprintln(args.Out, "\033[31m#\033[0m")
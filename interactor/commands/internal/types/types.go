@@ -1,15 +1,28 @@
 package types
 
 import (
+	"errors"
 	"os"
 
 	"github.com/cherrydra/mcpurl/llm"
 	"github.com/cherrydra/mcpurl/mcp/features"
 )
 
+// ErrBackground is returned by the commands needing the terminal when they run in a background job.
+var ErrBackground = errors.New("cannot use the terminal in a background job")
+
 type Arguments struct {
 	LLM      *llm.LLM
 	Features features.ServerFeatures
-	In, Out  *os.File
-	Args     []string
+	// Server is the server of the session of Features.
+	Server  string
+	In, Out *os.File
+	Err     *os.File
+	Args    []string
+	// Ask prompts the user for a line of input, nil if there is no one to ask.
+	Ask func(prompt string) (string, error)
+	// Background reports the command runs in a background job, it must not use the terminal.
+	Background bool
+	// ArgumentsFile keeps the last arguments edited for each tool of each server.
+	ArgumentsFile string
 }
@@ -0,0 +1,255 @@
+package commands
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/cherrydra/mcpurl/mcp/client"
+	"github.com/cherrydra/mcpurl/mcp/features"
+	"github.com/cherrydra/mcpurl/mcp/transport"
+	"github.com/cherrydra/mcpurl/parser"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// DefaultSession names the session of a server connected without a name or profile.
+const DefaultSession = "default"
+
+// serverSession is a named session of the interactor.
+type serverSession struct {
+	server  string
+	session *mcp.ClientSession
+}
+
+func sessionName(args parser.Arguments) string {
+	return cmp.Or(args.Profile, DefaultSession)
+}
+
+// adopt registers the session connected from the command line, c.mu must be held.
+func (c *Commands) adopt() {
+	if c.sessions == nil {
+		c.sessions = map[string]*serverSession{}
+	}
+	if c.Session != nil && c.current == "" {
+		c.current = sessionName(c.Args)
+		c.sessions[c.current] = &serverSession{c.Args.Server(), c.Session}
+	}
+}
+
+// Sessions returns the sessions by name.
+func (c *Commands) Sessions() map[string]*mcp.ClientSession {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.adopt()
+	ret := map[string]*mcp.ClientSession{}
+	for name, s := range c.sessions {
+		ret[name] = s.session
+	}
+	return ret
+}
+
+// CurrentSession returns the name of the current session, empty if there is none.
+func (c *Commands) CurrentSession() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.adopt()
+	return c.current
+}
+
+// CurrentServer returns the server of the current session, empty if there is none.
+func (c *Commands) CurrentServer() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.currentServerSession().server
+}
+
+// currentSession returns the current session, nil if there is none.
+func (c *Commands) currentSession() *mcp.ClientSession {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.currentServerSession().session
+}
+
+// currentServerSession returns the current session with its server, c.mu must be held.
+func (c *Commands) currentServerSession() serverSession {
+	c.adopt()
+	if s, ok := c.sessions[c.current]; ok {
+		return *s
+	}
+	return serverSession{session: c.Session}
+}
+
+// qualifiedSession returns the session named by the "session:" prefix of a tool or prompt name and
+// the name without it, or the current session if name is not qualified by a session.
+func (c *Commands) qualifiedSession(name string) (serverSession, string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.adopt()
+	if prefix, rest, ok := strings.Cut(name, ":"); ok {
+		if s, ok := c.sessions[prefix]; ok {
+			return *s, rest
+		}
+	}
+	return c.currentServerSession(), name
+}
+
+func (c *Commands) connect(ctx context.Context, args []string, out *os.File) error {
+	var name string
+	if len(args) >= 2 && (args[0] == "-n" || args[0] == "--name") {
+		name, args = args[1], args[2:]
+	}
+	if len(args) == 0 {
+		return parser.ErrInvalidUsage
+	}
+
+	parsed := parser.Parser{}
+	if err := parsed.Parse(args); err != nil {
+		return fmt.Errorf("parse transport args: %w", err)
+	}
+	parsedArgs := parsed.Arguments()
+	parsedArgs.Silent = true
+	clientTransport, err := transport.Transport(parsedArgs)
+	if err != nil {
+		return fmt.Errorf("transport: %w", err)
+	}
+	session, err := client.Connect(ctx, clientTransport)
+	if err != nil {
+		return fmt.Errorf("connect mcp server: %w", err)
+	}
+	name = cmp.Or(name, sessionName(parsedArgs))
+
+	c.mu.Lock()
+	c.adopt()
+	if old, ok := c.sessions[name]; ok {
+		json.NewEncoder(out).Encode(map[string]string{"msg": "disconnecting", "session": name})
+		client.Close(old.session)
+	}
+	c.sessions[name] = &serverSession{parsedArgs.Server(), session}
+	c.current, c.Session = name, session
+	c.mu.Unlock()
+	return c.showStatus(ctx, out)
+}
+
+// disconnect closes the named session, the current one by default.
+func (c *Commands) disconnect(ctx context.Context, args []string, out *os.File) error {
+	c.mu.Lock()
+	c.adopt()
+	name := c.current
+	if len(args) > 0 {
+		name = args[0]
+	}
+	s, ok := c.sessions[name]
+	if !ok {
+		c.mu.Unlock()
+		if len(args) > 0 {
+			return fmt.Errorf("unknown session: %s", name)
+		}
+		return nil
+	}
+	json.NewEncoder(out).Encode(map[string]string{"msg": "disconnecting", "session": name})
+	client.Close(s.session)
+	delete(c.sessions, name)
+	if name == c.current {
+		c.current, c.Session = "", nil
+	}
+	c.mu.Unlock()
+	return c.showStatus(ctx, out)
+}
+
+// use switches the current session.
+func (c *Commands) use(ctx context.Context, args []string, out *os.File) error {
+	if len(args) != 1 {
+		return parser.ErrInvalidUsage
+	}
+	c.mu.Lock()
+	c.adopt()
+	s, ok := c.sessions[args[0]]
+	if ok {
+		c.current, c.Session = args[0], s.session
+	}
+	c.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("unknown session: %s", args[0])
+	}
+	return c.showStatus(ctx, out)
+}
+
+type sessionStatus struct {
+	Name    string `json:"name"`
+	Server  string `json:"server,omitzero"`
+	Status  string `json:"status"`
+	Current bool   `json:"current,omitzero"`
+}
+
+// sessionStatuses pings the sessions, ordered by name.
+func (c *Commands) sessionStatuses(ctx context.Context) []sessionStatus {
+	c.mu.Lock()
+	c.adopt()
+	sessions := maps.Clone(c.sessions)
+	current := c.current
+	c.mu.Unlock()
+
+	var ret []sessionStatus
+	for _, name := range slices.Sorted(maps.Keys(sessions)) {
+		s := sessions[name]
+		ret = append(ret, sessionStatus{
+			Name:    name,
+			Server:  s.server,
+			Status:  pingStatus(ctx, s.session),
+			Current: name == current,
+		})
+	}
+	return ret
+}
+
+func pingStatus(ctx context.Context, session *mcp.ClientSession) string {
+	if session == nil {
+		return features.ErrNoSession.Error()
+	}
+	status := "connected"
+	if sid := session.ID(); sid != "" {
+		status = fmt.Sprintf("connected (%s)", sid)
+	}
+	if err := session.Ping(ctx, nil); err != nil {
+		status = "unhealth"
+	}
+	return status
+}
+
+// showSessions prints the sessions as json lines.
+func (c *Commands) showSessions(ctx context.Context, out *os.File) error {
+	for _, s := range c.sessionStatuses(ctx) {
+		json.NewEncoder(out).Encode(s)
+	}
+	return nil
+}
+
+func (c *Commands) showStatus(ctx context.Context, out *os.File) error {
+	status := struct {
+		LLM      string          `json:"llm,omitzero"`
+		Model    string          `json:"model,omitzero"`
+		Session  string          `json:"session,omitzero"`
+		Server   string          `json:"server,omitzero"`
+		Status   string          `json:"status,omitzero"`
+		Sessions []sessionStatus `json:"sessions,omitzero"`
+	}{
+		LLM:    c.Args.LLMBaseURL,
+		Model:  c.Args.LLMName,
+		Status: features.ErrNoSession.Error(),
+	}
+	sessions := c.sessionStatuses(ctx)
+	for _, s := range sessions {
+		if s.Current {
+			status.Session, status.Server, status.Status = s.Name, s.Server, s.Status
+		}
+	}
+	if len(sessions) > 1 {
+		status.Sessions = sessions
+	}
+	return json.NewEncoder(out).Encode(status)
+}
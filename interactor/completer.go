@@ -2,13 +2,17 @@ package interactor
 
 import (
 	"context"
+	"maps"
 	"os"
+	"slices"
 	"strings"
 	"sync"
 
+	"github.com/cherrydra/mcpurl/config"
 	"github.com/cherrydra/mcpurl/mcp/features"
 	"github.com/google/shlex"
 	"github.com/mcpurl/readline"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
 var (
@@ -16,8 +20,12 @@ var (
 )
 
 type mcpurlCompleter struct {
-	ctx     context.Context
-	session func() *features.ServerFeatures
+	ctx        context.Context
+	configFile string
+	// current and sessions return the current session name and the sessions by name.
+	current  func() string
+	sessions func() map[string]*mcp.ClientSession
+	aliases  func() []string
 
 	once      sync.Once
 	completer *readline.PrefixCompleter
@@ -26,6 +34,9 @@ type mcpurlCompleter struct {
 func (c *mcpurlCompleter) Do(line []rune, pos int) (newLine [][]rune, length int) {
 	c.once.Do(func() {
 		c.completer = readline.NewPrefixCompleter(
+			readline.PcItemDynamic(func(string) []string { return c.aliases() }),
+			readline.PcItem("alias"),
+			readline.PcItem("unalias", readline.PcItemDynamic(func(string) []string { return c.aliases() })),
 			readline.PcItem("tools"),
 			readline.PcItem("prompts"),
 			readline.PcItem("resources"),
@@ -48,9 +59,18 @@ func (c *mcpurlCompleter) Do(line []rune, pos int) (newLine [][]rune, length int
 				readline.PcItem("use"),
 				readline.PcItem("pop"),
 			),
-			readline.PcItem("connect"),
-			readline.PcItem("disconnect"),
+			readline.PcItem("connect", readline.PcItemDynamic(c.listProfiles), readline.PcItem("--name")),
+			readline.PcItem("disconnect", readline.PcItemDynamic(c.listSessions)),
+			readline.PcItem("use", readline.PcItemDynamic(c.listSessions)),
+			readline.PcItem("sessions"),
 			readline.PcItem("status"),
+			readline.PcItem("info"),
+			readline.PcItem("refresh"),
+			readline.PcItem("count"),
+			readline.PcItem("grep"),
+			readline.PcItem("head"),
+			readline.PcItem("json"),
+			readline.PcItem("table"),
 			readline.PcItem("cat", readline.PcItemDynamic(func(s string) []string {
 				return searchFiles(s, "", FILE_SEARCH_MODE_ONLY_FILES)
 			})),
@@ -62,11 +82,18 @@ func (c *mcpurlCompleter) Do(line []rune, pos int) (newLine [][]rune, length int
 			readline.PcItem("export"),
 			readline.PcItem("env"),
 			readline.PcItem("help"),
+			readline.PcItem("history", readline.PcItem("--all"), readline.PcItem("--failed")),
 			readline.PcItem("ls", readline.PcItemDynamic(func(s string) []string {
 				return searchFiles(s, "", FILE_SEARCH_MODE_ONLY_DIRS)
 			})),
 			readline.PcItem("pwd"),
+			readline.PcItem("repeat"),
+			readline.PcItem("time"),
+			readline.PcItem("source", readline.PcItemDynamic(func(s string) []string {
+				return searchFiles(s, "", FILE_SEARCH_MODE_ONLY_FILES)
+			})),
 			readline.PcItem("version"),
+			readline.PcItem("watch", readline.PcItem("-n"), readline.PcItem("--diff")),
 		)
 	})
 	return c.completer.Do(line, pos)
@@ -74,46 +101,90 @@ func (c *mcpurlCompleter) Do(line []rune, pos int) (newLine [][]rune, length int
 
 func (c *mcpurlCompleter) listTools(prefix string) (ret []string) {
 	args, _ := shlex.Split(prefix)
-	tools, err := c.session().ListTools(c.ctx)
-	if err != nil {
-		return nil
-	}
-	for _, tool := range tools {
-		if len(args) > 1 && !strings.HasPrefix(tool.Name, args[1]) {
-			continue
+	c.eachSession(func(f *features.ServerFeatures, qualifier string) {
+		tools, err := f.ListTools(c.ctx)
+		if err != nil {
+			return
 		}
-		ret = append(ret, tool.Name)
-	}
+		for _, tool := range tools {
+			name := qualifier + tool.Name
+			if len(args) > 1 && !strings.HasPrefix(name, args[1]) {
+				continue
+			}
+			ret = append(ret, name)
+		}
+	})
 	return
 }
 
 func (c *mcpurlCompleter) listPrompts(prefix string) (ret []string) {
 	args, _ := shlex.Split(prefix)
-	prompts, err := c.session().ListPrompts(c.ctx)
-	if err != nil {
-		return nil
-	}
-	for _, prompt := range prompts {
-		if len(args) > 1 && !strings.HasPrefix(prompt.Name, args[1]) {
-			continue
+	c.eachSession(func(f *features.ServerFeatures, qualifier string) {
+		prompts, err := f.ListPrompts(c.ctx)
+		if err != nil {
+			return
 		}
-		ret = append(ret, prompt.Name)
-	}
+		for _, prompt := range prompts {
+			name := qualifier + prompt.Name
+			if len(args) > 1 && !strings.HasPrefix(name, args[1]) {
+				continue
+			}
+			ret = append(ret, name)
+		}
+	})
 	return
 }
 
 func (c *mcpurlCompleter) listResources(prefix string) (ret []string) {
 	args, _ := shlex.Split(prefix)
-	resources, err := c.session().ListResources(c.ctx)
+	c.eachSession(func(f *features.ServerFeatures, qualifier string) {
+		if qualifier != "" {
+			// resources are read from the current session only, a qualifier would be taken for a uri scheme
+			return
+		}
+		resources, err := f.ListResources(c.ctx)
+		if err != nil {
+			return
+		}
+		for _, resource := range resources {
+			name := qualifier + resource.Name
+			if len(args) > 1 && !strings.HasPrefix(name, args[1]) {
+				continue
+			}
+			ret = append(ret, name)
+		}
+	})
+	return
+}
+
+// eachSession calls list with the current session first, then with the other sessions
+// and the qualifier of their names.
+func (c *mcpurlCompleter) eachSession(list func(f *features.ServerFeatures, qualifier string)) {
+	current := c.current()
+	sessions := c.sessions()
+	if session, ok := sessions[current]; ok {
+		list(&features.ServerFeatures{Session: session}, "")
+	}
+	for _, name := range slices.Sorted(maps.Keys(sessions)) {
+		if name != current {
+			list(&features.ServerFeatures{Session: sessions[name]}, name+":")
+		}
+	}
+}
+
+func (c *mcpurlCompleter) listSessions(prefix string) []string {
+	return slices.Sorted(maps.Keys(c.sessions()))
+}
+
+func (c *mcpurlCompleter) listProfiles(prefix string) (ret []string) {
+	conf, err := config.Profiles(c.configFile)
 	if err != nil {
 		return nil
 	}
-	for _, resource := range resources {
-		if len(args) > 1 && !strings.HasPrefix(resource.Name, args[1]) {
-			continue
-		}
-		ret = append(ret, resource.Name)
+	for name := range conf.Servers {
+		ret = append(ret, "@"+name)
 	}
+	slices.Sort(ret)
 	return
 }
 
@@ -0,0 +1,252 @@
+package interactor
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cherrydra/mcpurl/parser"
+	"github.com/mcpurl/readline"
+)
+
+// maxHistory is the number of entries kept in the history file.
+const maxHistory = 5000
+
+// historyEntry is a command line of the history with the server it was run against, its timing and exit status.
+type historyEntry struct {
+	Time     time.Time `json:"time,omitzero"`
+	Server   string    `json:"server,omitzero"`
+	Command  string    `json:"command"`
+	Duration duration  `json:"duration,omitzero"`
+	Status   int       `json:"status"`
+}
+
+// duration is a time.Duration encoded as a string such as "1.5s".
+type duration time.Duration
+
+func (d duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func (d *duration) UnmarshalText(b []byte) error {
+	v, err := time.ParseDuration(string(b))
+	*d = duration(v)
+	return err
+}
+
+// history is the command history of all the servers, kept in a json lines file.
+// The lines of the older plain text history files are read as commands without server.
+type history struct {
+	file string
+
+	mu      sync.Mutex
+	entries []historyEntry
+}
+
+func loadHistory(file string) (*history, error) {
+	h := &history{file: file}
+	if file == "" {
+		return h, nil
+	}
+	f, err := os.Open(file)
+	if os.IsNotExist(err) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open history: %w", err)
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var e historyEntry
+		if err := json.Unmarshal([]byte(line), &e); err != nil || e.Command == "" {
+			e = historyEntry{Command: line}
+		}
+		h.entries = append(h.entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read history: %w", err)
+	}
+	if len(h.entries) > maxHistory {
+		h.entries = slices.Clone(h.entries[len(h.entries)-maxHistory:])
+		if err := h.rewrite(); err != nil {
+			return nil, err
+		}
+	}
+	return h, nil
+}
+
+// rewrite replaces the history file with the entries.
+func (h *history) rewrite() error {
+	f, err := os.CreateTemp(filepath.Dir(h.file), ".mcpurl_history")
+	if err != nil {
+		return fmt.Errorf("rewrite history: %w", err)
+	}
+	defer os.Remove(f.Name())
+	w := bufio.NewWriter(f)
+	for _, e := range h.entries {
+		if err := json.NewEncoder(w).Encode(e); err != nil {
+			f.Close()
+			return fmt.Errorf("rewrite history: %w", err)
+		}
+	}
+	if err := errors.Join(w.Flush(), f.Close()); err != nil {
+		return fmt.Errorf("rewrite history: %w", err)
+	}
+	if err := os.Rename(f.Name(), h.file); err != nil {
+		return fmt.Errorf("rewrite history: %w", err)
+	}
+	return nil
+}
+
+// add appends the entry to the history and its file.
+func (h *history) add(e historyEntry) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.entries = append(h.entries, e)
+	if h.file == "" {
+		return nil
+	}
+	f, err := os.OpenFile(h.file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("save history: %w", err)
+	}
+	defer f.Close()
+	if err := json.NewEncoder(f).Encode(e); err != nil {
+		return fmt.Errorf("save history: %w", err)
+	}
+	return nil
+}
+
+// view returns the entries of the server, the entries run without session if server is empty.
+// The entries are numbered from 1 in the order of the view, the numbers of !n and of the history builtin.
+func (h *history) view(server string) []historyEntry {
+	if h == nil {
+		return nil
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var ret []historyEntry
+	for _, e := range h.entries {
+		if e.Server == server {
+			ret = append(ret, e)
+		}
+	}
+	return ret
+}
+
+// all returns the entries of all the servers.
+func (h *history) all() []historyEntry {
+	if h == nil {
+		return nil
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return slices.Clone(h.entries)
+}
+
+// seed replaces the readline history, recalled by the arrow keys, with the commands of the server.
+// It is called when the server changes, the commands run in between are appended by addHistory.
+func (h *history) seed(l *readline.Instance, server string, limit int) {
+	entries := h.view(server)
+	if len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+	l.ResetHistory()
+	for _, e := range entries {
+		_ = l.SaveHistory(e.Command)
+	}
+}
+
+var historyEvent = regexp.MustCompile(`^!(!|-?\d+)`)
+
+// expandHistory replaces the leading event of the command line, !! for the previous command,
+// !n for the command numbered n and !-n for the n-th previous one, with the command of the history.
+func (ia *Interactor) expandHistory(command string) (string, bool, error) {
+	m := historyEvent.FindStringSubmatch(command)
+	if m == nil {
+		return command, false, nil
+	}
+	entries := ia.history.view(ia.Commands.CurrentServer())
+	n := -1
+	if m[1] != "!" {
+		n, _ = strconv.Atoi(m[1])
+	}
+	i := n - 1
+	if n < 0 {
+		i = len(entries) + n
+	}
+	if i < 0 || i >= len(entries) {
+		return "", false, fmt.Errorf("%s: event not found", m[0])
+	}
+	return entries[i].Command + command[len(m[0]):], true, nil
+}
+
+// showHistory prints the numbered history of the current server as json lines, the last n entries if given.
+// -a shows the history of all the servers, the entries of the other servers are not numbered as !n
+// does not recall them, and --failed the commands with a non-zero exit status.
+func (ia *Interactor) showHistory(args []string, out io.Writer) error {
+	var all, failed bool
+	n := -1
+	for _, arg := range args {
+		switch arg {
+		case "-a", "--all":
+			all = true
+		case "-f", "--failed":
+			failed = true
+		default:
+			v, err := strconv.Atoi(arg)
+			if err != nil || v < 0 || n >= 0 {
+				return parser.ErrInvalidUsage
+			}
+			n = v
+		}
+	}
+	server := ia.Commands.CurrentServer()
+	type numbered struct {
+		N int `json:"n,omitzero"`
+		historyEntry
+	}
+	var entries []numbered
+	view := ia.history.view(server)
+	if all {
+		view = ia.history.all()
+	}
+	i := 0
+	for _, e := range view {
+		ne := numbered{historyEntry: e}
+		if e.Server == server {
+			i++
+			ne.N = i
+		}
+		if failed && e.Status == 0 {
+			continue
+		}
+		entries = append(entries, ne)
+	}
+	if n >= 0 && len(entries) > n {
+		entries = entries[len(entries)-n:]
+	}
+	enc := json.NewEncoder(out)
+	for _, e := range entries {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
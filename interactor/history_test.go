@@ -0,0 +1,159 @@
+package interactor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cherrydra/mcpurl/interactor/commands"
+	"github.com/cherrydra/mcpurl/parser"
+)
+
+// newTestInteractor returns an interactor without session whose history has the commands,
+// the commands prefixed with "@server " are run against that server.
+func newTestInteractor(t *testing.T, commandLines ...string) *Interactor {
+	t.Helper()
+	h, err := loadHistory("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range commandLines {
+		e := historyEntry{Command: line}
+		if server, command, ok := strings.Cut(line, " "); ok && strings.HasPrefix(server, "@") {
+			e = historyEntry{Server: server, Command: command}
+		}
+		if strings.Contains(line, "fail") {
+			e.Status = 1
+		}
+		if err := h.add(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return &Interactor{Commands: &commands.Commands{}, history: h}
+}
+
+func TestExpandHistory(t *testing.T) {
+	ia := newTestInteractor(t, "tools", "@fs tool read", "prompts", "@fs tool write", "resources")
+	tests := []struct {
+		command  string
+		want     string
+		expanded bool
+	}{
+		{"tools", "tools", false},
+		{"echo !!", "echo !!", false},
+		{"!!", "resources", true},
+		{"!! | count", "resources | count", true},
+		{"!1", "tools", true},
+		{"!2", "prompts", true},
+		{"!3 -l", "resources -l", true},
+		{"!-1", "resources", true},
+		{"!-3", "tools", true},
+		{"!1x", "toolsx", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			got, expanded, err := ia.expandHistory(tt.command)
+			if err != nil {
+				t.Fatalf("expandHistory(%q) error: %v", tt.command, err)
+			}
+			if got != tt.want || expanded != tt.expanded {
+				t.Errorf("expandHistory(%q) = %q, %v, want %q, %v", tt.command, got, expanded, tt.want, tt.expanded)
+			}
+		})
+	}
+}
+
+func TestExpandHistoryError(t *testing.T) {
+	ia := newTestInteractor(t, "tools", "@fs tool read")
+	for _, command := range []string{"!0", "!2", "!-2", "!-0"} {
+		if _, _, err := ia.expandHistory(command); err == nil || !strings.Contains(err.Error(), "event not found") {
+			t.Errorf("expandHistory(%q) error = %v, want event not found", command, err)
+		}
+	}
+	if _, _, err := newTestInteractor(t).expandHistory("!!"); err == nil {
+		t.Error("expandHistory(!!) of an empty history succeeded")
+	}
+}
+
+func TestShowHistory(t *testing.T) {
+	ia := newTestInteractor(t, "tools", "@fs tool read", "fail 1", "@fs fail 2", "resources")
+	tests := []struct {
+		args []string
+		want string // the number and command of the entries, n is 0 for the entries not recalled by !n
+	}{
+		{nil, "1 tools, 2 fail 1, 3 resources"},
+		{[]string{"2"}, "2 fail 1, 3 resources"},
+		{[]string{"0"}, ""},
+		{[]string{"--failed"}, "2 fail 1"},
+		{[]string{"-a"}, "1 tools, 0 tool read, 2 fail 1, 0 fail 2, 3 resources"},
+		{[]string{"-a", "-f", "1"}, "0 fail 2"},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			var out bytes.Buffer
+			if err := ia.showHistory(tt.args, &out); err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			decoder := json.NewDecoder(&out)
+			for decoder.More() {
+				var e struct {
+					N       int    `json:"n"`
+					Command string `json:"command"`
+				}
+				if err := decoder.Decode(&e); err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, fmt.Sprintf("%d %s", e.N, e.Command))
+			}
+			if strings.Join(got, ", ") != tt.want {
+				t.Errorf("history %q = %q, want %q", tt.args, strings.Join(got, ", "), tt.want)
+			}
+		})
+	}
+	for _, args := range [][]string{{"x"}, {"-1"}, {"1", "2"}} {
+		if err := ia.showHistory(args, &bytes.Buffer{}); err != parser.ErrInvalidUsage {
+			t.Errorf("history %q error = %v, want %v", args, err, parser.ErrInvalidUsage)
+		}
+	}
+}
+
+func TestLoadHistory(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "history")
+	content := "tools\n\n" + `{"server":"@fs","command":"tool read","duration":"1.5s","status":1}` + "\n" + `{"not":"an entry"}` + "\n"
+	if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	h, err := loadHistory(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := h.add(historyEntry{Server: "@fs", Command: "prompts"}); err != nil {
+		t.Fatal(err)
+	}
+	// the added entry is read back from the file
+	if h, err = loadHistory(file); err != nil {
+		t.Fatal(err)
+	}
+	var none, fs []string
+	for _, e := range h.view("") {
+		none = append(none, e.Command)
+	}
+	for _, e := range h.view("@fs") {
+		fs = append(fs, e.Command)
+	}
+	if got, want := strings.Join(none, ","), `tools,{"not":"an entry"}`; got != want {
+		t.Errorf("commands without server = %q, want %q", got, want)
+	}
+	if got, want := strings.Join(fs, ","), "tool read,prompts"; got != want {
+		t.Errorf("commands of @fs = %q, want %q", got, want)
+	}
+	if e := h.view("@fs")[0]; e.Status != 1 || time.Duration(e.Duration) != 1500*time.Millisecond {
+		t.Errorf("entry = %+v, want status 1 and duration 1.5s", e)
+	}
+}
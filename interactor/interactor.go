@@ -1,30 +1,43 @@
 package interactor
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"os"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/cherrydra/mcpurl/interactor/commands"
-	"github.com/cherrydra/mcpurl/mcp/features"
+	"github.com/cherrydra/mcpurl/interactor/shell"
 	"github.com/cherrydra/mcpurl/parser"
-	"github.com/google/shlex"
 	"github.com/mcpurl/readline"
 )
 
-var (
-	ErrInvalidPipe = errors.New("invalid pipe command")
-)
-
 type Interactor struct {
 	Commands *commands.Commands
 
 	completer *mcpurlCompleter
+
+	// script and args are the running script file and its arguments.
+	script string
+	args   []string
+	// status is the exit status of the last command line.
+	status int
+	// history is the command history, nil when not interactive.
+	history *history
+	// health is the health of the sessions shown by the prompt.
+	health healthCheck
+
+	mu      sync.Mutex
+	vars    map[string]string
+	jobs    map[int]*job
+	aliases map[string]string
 }
 
 func (i *Interactor) Run(ctx context.Context) error {
@@ -53,19 +66,30 @@ func (i *Interactor) Run(ctx context.Context) error {
 	}
 
 	i.completer = &mcpurlCompleter{
-		ctx:     ctx,
-		session: func() *features.ServerFeatures { return &features.ServerFeatures{Session: i.Commands.Session} },
+		ctx:        ctx,
+		configFile: i.Commands.Args.ConfigFile,
+		current:    i.Commands.CurrentSession,
+		sessions:   i.Commands.Sessions,
+		aliases:    i.aliasNames,
+	}
+
+	var err error
+	if i.history, err = loadHistory(i.Commands.Args.HistoryFile); err != nil {
+		slog.Warn("load history", "error", err)
+		i.history = &history{file: i.Commands.Args.HistoryFile}
 	}
 
+	prompt := i.prompt(ctx)
 	l, err := readline.NewEx(&readline.Config{
-		Prompt:          "\033[36mmcpurl>\033[0m ",
+		Prompt:          prompt,
 		AutoComplete:    i.completer,
 		InterruptPrompt: "^C",
 		EOFPrompt:       "exit",
 
-		HistoryFile:         i.Commands.Args.HistoryFile,
-		HistorySearchFold:   true,
-		FuncFilterInputRune: filterInput,
+		// the history is saved with the server, timing and status of the commands
+		DisableAutoSaveHistory: true,
+		HistorySearchFold:      true,
+		FuncFilterInputRune:    filterInput,
 	})
 	if err != nil {
 		return fmt.Errorf("create readline: %w", err)
@@ -73,6 +97,17 @@ func (i *Interactor) Run(ctx context.Context) error {
 
 	defer l.Close()
 	defer i.Commands.Close()
+	defer i.killJobs()
+
+	i.Commands.Ask = func(question string) (string, error) {
+		l.HistoryDisable()
+		defer l.HistoryEnable()
+		l.SetPrompt(question)
+		defer l.SetPrompt(prompt)
+		return l.Readline()
+	}
+
+	i.loadRC(ctx, i.Commands.Args.RCFile)
 
 	var executionCtx context.Context
 	var executionCancel context.CancelFunc
@@ -85,8 +120,18 @@ func (i *Interactor) Run(ctx context.Context) error {
 		l.Close()
 	})
 
-readLoop:
+	// the readline history is seeded with the commands of the server when the server changes
+	var seeded bool
+	var seededServer string
 	for {
+		i.notifyJobs(os.Stderr)
+		server := i.Commands.CurrentServer()
+		if !seeded || server != seededServer {
+			i.history.seed(l, server, l.Config.HistoryLimit)
+			seeded, seededServer = true, server
+		}
+		prompt = i.prompt(ctx)
+		l.SetPrompt(prompt)
 		line, err := l.Readline()
 		if err == io.EOF {
 			break
@@ -102,99 +147,464 @@ readLoop:
 		if command == "" {
 			continue
 		}
+		command, err = continueCommand(command, func() (string, error) {
+			l.SetPrompt(i.continuationPrompt())
+			return l.Readline()
+		})
+		if err == readline.ErrInterrupt {
+			continue
+		}
+		command, expanded, err := i.expandHistory(command)
+		if err != nil {
+			i.report(err)
+			continue
+		}
+		if expanded {
+			fmt.Fprintln(os.Stderr, command)
+		}
 
+		start := time.Now()
 		executionCtx, executionCancel = context.WithCancel(ctx)
-		err = i.executeCommand(executionCtx, command)
+		err = i.executeCommand(executionCtx, command, osStdio())
 		executionCancel()
 		executionCancel = nil
-		switch err {
-		case parser.ErrInvalidUsage:
-			_ = i.Commands.PrintUsage()
-		case nil, context.Canceled: // just ignore
-		case os.ErrProcessDone:
-			break readLoop
-		default:
-			fmt.Fprintln(os.Stderr, "Error:", err)
+		i.addHistory(server, command, start, err)
+		_ = l.SaveHistory(command)
+		if errors.Is(err, os.ErrProcessDone) {
+			break
+		}
+		i.report(err)
+		var syntaxErr *shell.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			fmt.Fprintf(os.Stderr, "  %s\n  %*s\n", command, syntaxErr.Column, "^")
 		}
 	}
 	return nil
 }
 
-func (ia *Interactor) executeCommand(ctx context.Context, command string) (err error) {
-	// io redirect
-	stdout := os.Stdout
-	stdin := os.Stdin
-	redirAppendParts := strings.Split(command, ">>")
-	redirCreateParts := strings.Split(redirAppendParts[0], ">")
-	var redirPart, redirMode string
-	if len(redirAppendParts) > 1 {
-		redirPart = strings.TrimSpace(redirAppendParts[len(redirAppendParts)-1])
-		redirMode = "append"
-	} else if len(redirCreateParts) > 1 {
-		redirPart = strings.TrimSpace(redirCreateParts[len(redirCreateParts)-1])
-		redirMode = "create"
-	}
-	switch redirMode {
-	case "append":
-		stdout, err = os.OpenFile(redirPart, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+// addHistory saves the command line run against the server with its timing and exit status.
+func (ia *Interactor) addHistory(server, command string, start time.Time, err error) {
+	e := historyEntry{Time: start, Server: server, Command: command, Duration: duration(time.Since(start).Round(time.Microsecond))}
+	if err != nil && !errors.Is(err, os.ErrProcessDone) {
+		e.Status = 1
+	}
+	if err := ia.history.add(e); err != nil {
+		slog.Warn("save history", "error", err)
+	}
+}
+
+func (ia *Interactor) executeCommand(ctx context.Context, command string, std stdio) error {
+	list, err := shell.Parse(command)
+	if err != nil {
+		return err
+	}
+	return ia.runList(ctx, list, std)
+}
+
+// continueCommand appends the lines read by next to the command line while it is incomplete,
+// ending inside quotes, brackets, a here-document, after a trailing \ or an operator expecting more.
+// The command line is returned as is if next fails with io.EOF, for reporting the syntax error.
+func continueCommand(command string, next func() (string, error)) (string, error) {
+	for {
+		var syntaxErr *shell.SyntaxError
+		if _, err := shell.Parse(command); !errors.As(err, &syntaxErr) || !syntaxErr.Incomplete {
+			return command, nil
+		}
+		line, err := next()
+		if err == io.EOF {
+			return command, nil
+		}
 		if err != nil {
-			return fmt.Errorf("open file for append: %w", err)
+			return "", err
+		}
+		command += "\n" + line
+	}
+}
+
+type stdio struct {
+	in, out, err *os.File
+}
+
+func osStdio() stdio {
+	return stdio{os.Stdin, os.Stdout, os.Stderr}
+}
+
+// reportedError is an error already printed to the redirected stderr of the command.
+type reportedError struct {
+	error
+}
+
+func (e reportedError) Unwrap() error {
+	return e.error
+}
+
+// report prints the error of a command line.
+func (ia *Interactor) report(err error) {
+	var reported reportedError
+	switch {
+	case err == nil, errors.Is(err, context.Canceled), errors.As(err, &reported):
+	case errors.Is(err, parser.ErrInvalidUsage):
+		_ = ia.Commands.PrintUsage()
+	default:
+		fmt.Fprintln(os.Stderr, "Error:", err)
+	}
+}
+
+// runList runs the and-or lists of the list, the ones ending with "&" are started as background jobs.
+// The error of a list is reported before running the next one and the error of the last one is returned.
+func (ia *Interactor) runList(ctx context.Context, list *shell.List, std stdio) error {
+	var last error
+	for i := 0; i < len(list.Items); {
+		j := i + 1
+		for j < len(list.Items) && list.Items[j].Op != shell.OpSeq {
+			j++
+		}
+		andOr := list.Items[i:j]
+		i = j
+
+		ia.report(last)
+		if andOr[len(andOr)-1].Background {
+			last = ia.startJob(ctx, andOr, std)
+			continue
+		}
+		last = ia.runAndOr(ctx, andOr, std)
+		if errors.Is(last, os.ErrProcessDone) || errors.Is(last, context.Canceled) {
+			return last
+		}
+	}
+	return last
+}
+
+// runAndOr runs the pipelines joined by "&&" and "||", returning the error of the last one run.
+func (ia *Interactor) runAndOr(ctx context.Context, items []shell.Item, std stdio) error {
+	var last error
+	for _, item := range items {
+		switch {
+		case item.Op == shell.OpAnd && last != nil:
+			continue
+		case item.Op == shell.OpOr && last == nil:
+			continue
+		}
+		last = ia.runPipeline(ctx, item.Pipeline, std)
+		ia.mu.Lock()
+		ia.status = 0
+		if last != nil {
+			ia.status = 1
+		}
+		ia.mu.Unlock()
+		if errors.Is(last, os.ErrProcessDone) || errors.Is(last, context.Canceled) {
+			return last
 		}
-	case "create":
-		stdout, err = os.Create(redirPart)
+	}
+	return last
+}
+
+// stage is a command of a pipeline with its expanded arguments.
+type stage struct {
+	stdio
+	args []string
+	// files are the pipes and redirected files closed when the stage is done.
+	files []*os.File
+}
+
+func (ia *Interactor) runPipeline(ctx context.Context, pipeline shell.Pipeline, std stdio) error {
+	if name, pipeline, ok := assignment(pipeline); ok {
+		return ia.assign(ctx, name, pipeline, std)
+	}
+	if name, word, ok := plainAssignment(pipeline); ok {
+		value, err := ia.expandWord(ctx, word)
 		if err != nil {
-			return fmt.Errorf("create file: %w", err)
+			return err
 		}
+		ia.setVariable(name, value)
+		return nil
 	}
 
-	// pipeline
-	pipeParts := strings.Split(redirCreateParts[0], "|")
-	errChan := make(chan error, 1)
+	stages := make([]stage, len(pipeline.Commands))
+	for i := range stages {
+		stages[i].stdio = std
+	}
+	closeAll := func() {
+		for _, s := range stages {
+			closeFiles(s.files)
+		}
+	}
+	for i := 0; i < len(stages)-1; i++ {
+		r, w, err := os.Pipe()
+		if err != nil {
+			closeAll()
+			return fmt.Errorf("create pipe: %w", err)
+		}
+		stages[i].out, stages[i+1].in = w, r
+		stages[i].files = append(stages[i].files, w)
+		stages[i+1].files = append(stages[i+1].files, r)
+	}
+	// expand the words before starting the pipeline, so that the command substitutions run in order
+	for i, command := range pipeline.Commands {
+		var err error
+		if stages[i].args, err = ia.expandWords(ctx, command.Words); err == nil {
+			err = ia.redirect(ctx, &stages[i], command.Redirects)
+		}
+		if err != nil {
+			closeAll()
+			return err
+		}
+	}
+
+	// the output of the commands printed to the terminal is kept as the result
+	var results []*teeResult
+	for i := range stages {
+		s := &stages[i]
+		if s.out != os.Stdout || len(s.args) == 0 || !commands.Builtin(s.args[0]) {
+			continue
+		}
+		r, w, err := os.Pipe()
+		if err != nil {
+			closeAll()
+			return fmt.Errorf("create pipe: %w", err)
+		}
+		s.out = w
+		s.files = append(s.files, w)
+		results = append(results, ia.tee(r))
+	}
+
+	errs := make([]error, len(stages))
 	var wg sync.WaitGroup
-	for i, part := range pipeParts {
-		thisIn := stdin
-		thisOut := stdout
-		if i < len(pipeParts)-1 {
-			stdin, thisOut, err = os.Pipe()
-			if err != nil {
-				errChan <- fmt.Errorf("create pipe for part %d: %w", i+1, err)
+	for i := range stages {
+		wg.Add(1)
+		go func(s *stage) {
+			defer wg.Done()
+			defer closeFiles(s.files)
+			if len(s.args) == 0 {
 				return
 			}
+			err := ia.exec(ctx, s.args[0], s.args[1:], s.stdio)
+			if err != nil && s.err != std.err && !errors.Is(err, os.ErrProcessDone) {
+				fmt.Fprintln(s.err, "Error:", err)
+				err = reportedError{err}
+			}
+			errs[i] = err
+		}(&stages[i])
+	}
+	wg.Wait()
+	var result strings.Builder
+	for _, t := range results {
+		<-t.done
+		result.Write(t.buf.Bytes())
+	}
+	if result.Len() > 0 {
+		ia.setVariable(resultVar, strings.TrimRight(result.String(), "\n"))
+	}
+
+	// the errors already reported to a redirected stderr only fail the pipeline
+	var failed []error
+	var reported error
+	for _, err := range errs {
+		switch {
+		case err == nil:
+		case errors.As(err, &reportedError{}):
+			if reported == nil {
+				reported = err
+			}
+		default:
+			failed = append(failed, err)
 		}
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			defer func() {
-				if thisIn.Fd() != os.Stdin.Fd() {
-					thisIn.Close()
-				}
-				if thisOut.Fd() != os.Stdout.Fd() {
-					thisOut.Close()
-				}
-			}()
-			args, err := shlex.Split(part)
+	}
+	switch len(failed) {
+	case 0:
+		return reported
+	case 1:
+		return failed[0]
+	}
+	return errors.Join(failed...)
+}
+
+func (ia *Interactor) redirect(ctx context.Context, s *stage, redirects []shell.Redirect) error {
+	for _, r := range redirects {
+		if r.Op == ">&" {
+			s.err = s.out
+			continue
+		}
+		name, err := ia.expandWord(ctx, r.Target)
+		if err != nil {
+			return err
+		}
+		var f *os.File
+		switch r.Op {
+		case "<":
+			f, err = os.Open(name)
+		case "<<":
+			f, err = hereDocument(name)
+		case ">":
+			f, err = os.Create(name)
+		case ">>":
+			f, err = os.OpenFile(name, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		}
+		if err != nil {
+			return fmt.Errorf("redirect: %w", err)
+		}
+		s.files = append(s.files, f)
+		switch r.Fd {
+		case 0:
+			s.in = f
+		case 1:
+			s.out = f
+		case 2:
+			s.err = f
+		}
+	}
+	return nil
+}
+
+// hereDocument returns a pipe reading the body of the here-document.
+func hereDocument(body string) (*os.File, error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		defer w.Close()
+		io.WriteString(w, body)
+	}()
+	return r, nil
+}
+
+func closeFiles(files []*os.File) {
+	for _, f := range files {
+		f.Close()
+	}
+}
+
+func (ia *Interactor) expandWords(ctx context.Context, words []shell.Word) ([]string, error) {
+	var args []string
+	for _, word := range words {
+		// "$@" expands to the script arguments as separate words
+		if len(word.Parts) == 1 && word.Parts[0].Kind == shell.Variable && word.Parts[0].Text == "@" {
+			args = append(args, ia.args...)
+			continue
+		}
+		arg, err := ia.expandWord(ctx, word)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	return args, nil
+}
+
+func (ia *Interactor) expandWord(ctx context.Context, word shell.Word) (string, error) {
+	var b strings.Builder
+	for _, part := range word.Parts {
+		switch part.Kind {
+		case shell.Literal:
+			b.WriteString(part.Text)
+		case shell.Variable:
+			b.WriteString(ia.lookup(part.Text))
+		case shell.Substitution:
+			out, err := ia.substitute(ctx, part.Subst)
 			if err != nil {
-				errChan <- fmt.Errorf(`parse "%s": %w`, strings.TrimSpace(part), err)
+				return "", fmt.Errorf("command substitution: %w", err)
 			}
-			if err := ia.Commands.Exec(ctx, args[0], args[1:], thisIn, thisOut); err != nil {
-				errChan <- err
-			}
-		}()
+			b.WriteString(out)
+		}
+	}
+	return b.String(), nil
+}
+
+// lookup returns the value of the script arguments, the status of the last command line,
+// the interactor variable or the environment variable.
+func (ia *Interactor) lookup(name string) string {
+	switch name {
+	case "0":
+		return cmp.Or(ia.script, "mcpurl")
+	case "#":
+		return strconv.Itoa(len(ia.args))
+	case "@", "*":
+		return strings.Join(ia.args, " ")
+	case "?":
+		ia.mu.Lock()
+		defer ia.mu.Unlock()
+		return strconv.Itoa(ia.status)
+	}
+	if n, err := strconv.Atoi(name); err == nil {
+		if n >= 1 && n <= len(ia.args) {
+			return ia.args[n-1]
+		}
+		return ""
+	}
+	if value, ok := ia.variable(name); ok {
+		return value
 	}
+	return os.Getenv(name)
+}
 
-	done := make(chan struct{})
+// substitute runs the list and returns its output without the trailing newlines.
+func (ia *Interactor) substitute(ctx context.Context, list *shell.List) (string, error) {
+	devNull, err := os.Open(os.DevNull)
+	if err != nil {
+		return "", fmt.Errorf("open %s: %w", os.DevNull, err)
+	}
+	defer devNull.Close()
+	r, w, err := os.Pipe()
+	if err != nil {
+		return "", fmt.Errorf("create pipe: %w", err)
+	}
+	defer r.Close()
+	errChan := make(chan error, 1)
 	go func() {
-		defer close(done)
-		wg.Wait()
-		close(errChan)
+		defer w.Close()
+		errChan <- ia.runList(ctx, list, stdio{devNull, w, os.Stderr})
 	}()
+	out, readErr := io.ReadAll(r)
+	if err := <-errChan; err != nil {
+		return "", err
+	}
+	if readErr != nil {
+		return "", fmt.Errorf("read output: %w", readErr)
+	}
+	return strings.TrimRight(string(out), "\n"), nil
+}
 
-	select {
-	case err = <-errChan:
-		return err
-	case <-done:
-		return nil
+// exec runs the aliases and the interactor builtins, other commands are passed to Commands.
+func (ia *Interactor) exec(ctx context.Context, command string, args []string, std stdio) error {
+	if value, ok := ia.alias(ctx, command); ok {
+		return ia.runAlias(ctx, command, value, args, std)
+	}
+	switch command {
+	case "alias":
+		return ia.defineAliases(args, std.out)
+	case "unalias":
+		return ia.removeAliases(args)
+	case "source", ".":
+		if len(args) == 0 {
+			return parser.ErrInvalidUsage
+		}
+		return ia.runScript(ctx, args[0], args[1:], std)
+	case "set":
+		if len(args) > 0 {
+			return parser.ErrInvalidUsage
+		}
+		return ia.showVariables(std.out)
+	case "unset":
+		return ia.unsetVariables(args)
+	case "jobs":
+		return ia.showJobs(std.out)
+	case "history":
+		return ia.showHistory(args, std.out)
+	case "fg":
+		return ia.foreground(ctx, args, std)
+	case "wait":
+		return ia.wait(ctx, args)
+	case "watch":
+		return ia.watch(ctx, args, std)
+	case "repeat":
+		return ia.repeat(ctx, args, std)
+	case "time":
+		return ia.time(ctx, args, std)
+	case "kill":
+		return ia.kill(args)
 	}
+	return ia.Commands.Exec(ctx, command, args, std.in, std.out, std.err)
 }
 
 func filterInput(r rune) (rune, bool) {
@@ -0,0 +1,264 @@
+package interactor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cherrydra/mcpurl/interactor/commands"
+	"github.com/cherrydra/mcpurl/interactor/shell"
+	"github.com/cherrydra/mcpurl/parser"
+)
+
+// job is a command line running in the background with its output captured.
+type job struct {
+	id       int
+	command  string
+	cancel   context.CancelFunc
+	output   jobOutput
+	notified bool
+
+	// err is set before done is closed.
+	done chan struct{}
+	err  error
+}
+
+func (j *job) status() string {
+	select {
+	case <-j.done:
+		if j.err != nil {
+			return "failed"
+		}
+		return "done"
+	default:
+		return "running"
+	}
+}
+
+// jobOutput buffers the output of a job until it is attached to the terminal by fg.
+type jobOutput struct {
+	mu     sync.Mutex
+	buf    bytes.Buffer
+	follow io.Writer
+}
+
+func (o *jobOutput) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.follow != nil {
+		return o.follow.Write(p)
+	}
+	return o.buf.Write(p)
+}
+
+// attach writes the buffered output to w and the following output directly to it.
+func (o *jobOutput) attach(w io.Writer) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	_, _ = w.Write(o.buf.Bytes())
+	o.buf.Reset()
+	o.follow = w
+}
+
+// startJob runs the and-or list in the background, the job is not cancelled with ctx
+// but by kill or when the interactor exits.
+func (ia *Interactor) startJob(ctx context.Context, items []shell.Item, std stdio) error {
+	devNull, err := os.Open(os.DevNull)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", os.DevNull, err)
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		devNull.Close()
+		return fmt.Errorf("create pipe: %w", err)
+	}
+	jobCtx, cancel := context.WithCancel(commands.Background(context.WithoutCancel(ctx)))
+	j := &job{command: jobCommand(items), cancel: cancel, done: make(chan struct{})}
+
+	ia.mu.Lock()
+	if ia.jobs == nil {
+		ia.jobs = map[int]*job{}
+	}
+	j.id = 1
+	for ia.jobs[j.id] != nil {
+		j.id++
+	}
+	ia.jobs[j.id] = j
+	ia.mu.Unlock()
+
+	copied := make(chan struct{})
+	go func() {
+		defer close(copied)
+		defer r.Close()
+		_, _ = io.Copy(&j.output, r)
+	}()
+	go func() {
+		defer close(j.done)
+		defer cancel()
+		j.err = ia.runAndOr(jobCtx, items, stdio{devNull, w, w})
+		w.Close()
+		devNull.Close()
+		<-copied
+	}()
+	fmt.Fprintf(std.err, "[%d] %s\n", j.id, j.command)
+	return nil
+}
+
+func jobCommand(items []shell.Item) string {
+	var b strings.Builder
+	for _, item := range items {
+		if item.Op != "" {
+			b.WriteString(" " + string(item.Op) + " ")
+		}
+		b.WriteString(item.Text)
+	}
+	return b.String()
+}
+
+// job returns the job numbered by the argument, "%n" or "n", the latest one by default.
+func (ia *Interactor) job(args []string) (*job, error) {
+	if len(args) > 1 {
+		return nil, parser.ErrInvalidUsage
+	}
+	ia.mu.Lock()
+	defer ia.mu.Unlock()
+	if len(args) == 0 {
+		if len(ia.jobs) == 0 {
+			return nil, fmt.Errorf("no jobs")
+		}
+		return ia.jobs[slices.Max(slices.Collect(maps.Keys(ia.jobs)))], nil
+	}
+	id, err := strconv.Atoi(strings.TrimPrefix(args[0], "%"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid job: %s", args[0])
+	}
+	j, ok := ia.jobs[id]
+	if !ok {
+		return nil, fmt.Errorf("no such job: %d", id)
+	}
+	return j, nil
+}
+
+func (ia *Interactor) removeJob(id int) {
+	ia.mu.Lock()
+	defer ia.mu.Unlock()
+	delete(ia.jobs, id)
+}
+
+func (ia *Interactor) sortedJobs() []*job {
+	ia.mu.Lock()
+	defer ia.mu.Unlock()
+	var ret []*job
+	for _, id := range slices.Sorted(maps.Keys(ia.jobs)) {
+		ret = append(ret, ia.jobs[id])
+	}
+	return ret
+}
+
+// showJobs prints the jobs as json lines.
+func (ia *Interactor) showJobs(out io.Writer) error {
+	for _, j := range ia.sortedJobs() {
+		status := struct {
+			ID      int    `json:"id"`
+			Status  string `json:"status"`
+			Command string `json:"command"`
+			Error   string `json:"error,omitzero"`
+		}{ID: j.id, Status: j.status(), Command: j.command}
+		if status.Status == "failed" {
+			status.Error = j.err.Error()
+		}
+		json.NewEncoder(out).Encode(status)
+	}
+	return nil
+}
+
+// foreground prints the output of the job and waits for it, interrupting fg cancels the job.
+func (ia *Interactor) foreground(ctx context.Context, args []string, std stdio) error {
+	j, err := ia.job(args)
+	if err != nil {
+		return err
+	}
+	j.output.attach(std.out)
+	select {
+	case <-j.done:
+	case <-ctx.Done():
+		j.cancel()
+		<-j.done
+	}
+	ia.removeJob(j.id)
+	return j.err
+}
+
+// wait waits for the job, or for all the jobs without an argument.
+func (ia *Interactor) wait(ctx context.Context, args []string) error {
+	jobs := ia.sortedJobs()
+	if len(args) > 0 {
+		j, err := ia.job(args)
+		if err != nil {
+			return err
+		}
+		jobs = []*job{j}
+	}
+	for _, j := range jobs {
+		select {
+		case <-j.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if len(args) > 0 {
+		return jobs[0].err
+	}
+	return nil
+}
+
+// kill cancels the job, the MCP requests in flight are cancelled with it, and drops it from the jobs.
+func (ia *Interactor) kill(args []string) error {
+	if len(args) == 0 {
+		return parser.ErrInvalidUsage
+	}
+	j, err := ia.job(args)
+	if err != nil {
+		return err
+	}
+	j.cancel()
+	ia.removeJob(j.id)
+	return nil
+}
+
+// notifyJobs reports the background jobs finished since the last notification.
+func (ia *Interactor) notifyJobs(w io.Writer) {
+	for _, j := range ia.sortedJobs() {
+		if status := j.status(); status != "running" && !j.notified {
+			j.notified = true
+			fmt.Fprintf(w, "[%d] %s  %s\n", j.id, status, j.command)
+		}
+	}
+}
+
+// killJobs cancels the jobs and waits a moment for them to finish.
+func (ia *Interactor) killJobs() {
+	jobs := ia.sortedJobs()
+	for _, j := range jobs {
+		j.cancel()
+		ia.removeJob(j.id)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	for _, j := range jobs {
+		select {
+		case <-j.done:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
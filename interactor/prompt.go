@@ -0,0 +1,151 @@
+package interactor
+
+import (
+	"cmp"
+	"context"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// promptVar is the variable holding the prompt template, the MCPURL_PS1 environment variable is used if unset.
+const promptVar = "PS1"
+
+// continuationVar is the variable holding the prompt of the continuation lines.
+const continuationVar = "PS2"
+
+// defaultPrompt shows the server of the current session, noSessionPrompt is shown without session.
+const (
+	defaultPrompt   = "mcpurl {server}> "
+	noSessionPrompt = "mcpurl> "
+)
+
+// healthTimeout is the timeout of the pings of {health}.
+const healthTimeout = 2 * time.Second
+
+var placeholder = regexp.MustCompile(`\{(\w+)\}`)
+
+// prompt returns the prompt template with its placeholders replaced, unknown placeholders are kept.
+//
+//	{server}  server of the current session
+//	{session} name of the current session
+//	{health}  "ok" if the server answered the last ping, "down" if not, "?" before its first answer, "-" without a session
+//	{ctx}     index of the current LLM context
+//	{model}   name of the LLM model
+//	{status}  exit status of the last command line
+//	{cwd}     working directory, the home directory is shown as ~
+func (ia *Interactor) prompt(ctx context.Context) string {
+	template, ok := ia.variable(promptVar)
+	if !ok {
+		template = os.Getenv("MCPURL_PS1")
+	}
+	if template == "" && ia.Commands.CurrentServer() == "" {
+		template = noSessionPrompt
+	}
+	template = cmp.Or(template, defaultPrompt)
+	prompt := placeholder.ReplaceAllStringFunc(template, func(m string) string {
+		value, ok := ia.placeholder(ctx, m[1:len(m)-1])
+		if !ok {
+			return m
+		}
+		return value
+	})
+	return "\033[36m" + prompt + "\033[0m"
+}
+
+// continuationPrompt returns the prompt of the lines continuing an incomplete command line.
+func (ia *Interactor) continuationPrompt() string {
+	prompt, ok := ia.variable(continuationVar)
+	if !ok {
+		prompt = "> "
+	}
+	return "\033[36m" + prompt + "\033[0m"
+}
+
+func (ia *Interactor) placeholder(ctx context.Context, name string) (string, bool) {
+	switch name {
+	case "server":
+		return ia.Commands.CurrentServer(), true
+	case "session":
+		return ia.Commands.CurrentSession(), true
+	case "health":
+		return ia.health.check(ctx, ia.Commands.Sessions(), ia.Commands.CurrentSession()), true
+	case "ctx":
+		if ia.Commands.LLM == nil {
+			return "", true
+		}
+		return strconv.Itoa(ia.Commands.LLM.ContextManger.CurrentIndex()), true
+	case "model":
+		if ia.Commands.LLM == nil {
+			return "", true
+		}
+		return ia.Commands.LLM.Model, true
+	case "status":
+		return ia.lookup("?"), true
+	case "cwd":
+		wd, err := os.Getwd()
+		if err != nil {
+			return "", true
+		}
+		if home, err := os.UserHomeDir(); err == nil {
+			if rel, err := filepath.Rel(home, wd); err == nil && !strings.HasPrefix(rel, "..") {
+				return filepath.Join("~", rel), true
+			}
+		}
+		return wd, true
+	}
+	return "", false
+}
+
+// healthCheck pings the sessions in the background so that the prompt never waits for a server,
+// the prompt shows the result of the last ping.
+type healthCheck struct {
+	mu      sync.Mutex
+	status  map[*mcp.ClientSession]string
+	pinging map[*mcp.ClientSession]bool
+}
+
+// check returns the last health of the current session and starts pinging it unless a ping is running.
+// The health of the sessions no longer connected is dropped.
+func (h *healthCheck) check(ctx context.Context, sessions map[string]*mcp.ClientSession, current string) string {
+	session, ok := sessions[current]
+	if !ok {
+		return "-"
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.status == nil {
+		h.status, h.pinging = map[*mcp.ClientSession]string{}, map[*mcp.ClientSession]bool{}
+	}
+	for s := range h.status {
+		if !slices.Contains(slices.Collect(maps.Values(sessions)), s) {
+			delete(h.status, s)
+		}
+	}
+	if !h.pinging[session] {
+		h.pinging[session] = true
+		go h.ping(ctx, session)
+	}
+	return cmp.Or(h.status[session], "?")
+}
+
+func (h *healthCheck) ping(ctx context.Context, session *mcp.ClientSession) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), healthTimeout)
+	defer cancel()
+	status := "ok"
+	if err := session.Ping(ctx, nil); err != nil {
+		status = "down"
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.status[session] = status
+	delete(h.pinging, session)
+}
@@ -0,0 +1,83 @@
+package interactor
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/cherrydra/mcpurl/parser"
+)
+
+// RunScript runs the interactor command lines of the file, "-" reads from stdin.
+// Lines starting with # are comments, "set -e" stops the script at the first failing line
+// and $1..$n, $# and $@ refer to the script arguments.
+func (ia *Interactor) RunScript(ctx context.Context, file string, args []string) error {
+	defer ia.killJobs()
+	return ia.runScript(ctx, file, args, osStdio())
+}
+
+func (ia *Interactor) runScript(ctx context.Context, file string, args []string, std stdio) error {
+	var r io.Reader = std.in
+	if file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			return fmt.Errorf("open script: %w", err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	script, scriptArgs := ia.script, ia.args
+	ia.script, ia.args = file, args
+	defer func() { ia.script, ia.args = script, scriptArgs }()
+
+	errExit := false
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		switch line {
+		case "set -e":
+			errExit = true
+			continue
+		case "set +e":
+			errExit = false
+			continue
+		}
+
+		start := lineNo
+		line, _ = continueCommand(line, func() (string, error) {
+			if !scanner.Scan() {
+				return "", io.EOF
+			}
+			lineNo++
+			return scanner.Text(), nil
+		})
+
+		err := ia.executeCommand(ctx, line, std)
+		switch {
+		case err == nil:
+			continue
+		case errors.Is(err, os.ErrProcessDone), errors.Is(err, context.Canceled):
+			return err
+		case errors.Is(err, parser.ErrInvalidUsage):
+			err = fmt.Errorf("invalid usage: %s", line)
+		}
+		err = fmt.Errorf("%s:%d: %w", file, start, err)
+		if errExit {
+			return err
+		}
+		ia.report(err)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read script: %w", err)
+	}
+	return nil
+}
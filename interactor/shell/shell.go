@@ -0,0 +1,622 @@
+// Package shell parses the interactor command lines: words with quotes, escapes, variables and
+// command substitutions, joined by pipes, redirections and the ";", "&", "&&" and "||" operators.
+// A word starting with { or [ is taken as is up to the matching bracket, so that json needs no quoting,
+// and a here-document (<<EOF) reads the lines following the command line up to the delimiter.
+package shell
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Op joins an item of a List to the previous one.
+type Op string
+
+const (
+	OpSeq Op = ";"
+	OpAnd Op = "&&"
+	OpOr  Op = "||"
+)
+
+// List is a sequence of pipelines.
+type List struct {
+	Items []Item
+}
+
+type Item struct {
+	// Op is empty for the first item.
+	Op       Op
+	Pipeline Pipeline
+	// Background reports the item ends with "&", running the and-or list ending here in the background.
+	Background bool
+	// Text is the source of the pipeline.
+	Text string
+}
+
+// Pipeline is a sequence of commands connected by "|".
+type Pipeline struct {
+	Commands []Command
+}
+
+type Command struct {
+	Words     []Word
+	Redirects []Redirect
+	// Pos is the column of the command in the input.
+	Pos int
+}
+
+// Redirect is one of the "<", "<<", ">", ">>", "2>", "2>>" and "2>&1" redirections.
+type Redirect struct {
+	Fd int
+	Op string
+	// Target is the file, the body of a here-document, it is empty for "2>&1".
+	Target Word
+	Pos    int
+}
+
+type PartKind int
+
+const (
+	Literal PartKind = iota
+	Variable
+	Substitution
+)
+
+// Part is a piece of a word, a literal text, a variable name or a command substitution.
+type Part struct {
+	Kind PartKind
+	// Text is the literal text or the variable name.
+	Text  string
+	Subst *List
+}
+
+type Word struct {
+	Parts []Part
+	Pos   int
+}
+
+// SyntaxError reports the column of the offending input.
+type SyntaxError struct {
+	Column int
+	Msg    string
+	// Incomplete reports the input ended inside a quote or a substitution, or after an operator
+	// expecting more, so that it could be completed by more input.
+	Incomplete bool
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("syntax error at column %d: %s", e.Column, e.Msg)
+}
+
+// Parse parses the command line, an empty line is an empty list.
+func Parse(input string) (*List, error) {
+	p := &parser{input: []rune(input)}
+	list, err := p.list(false)
+	if err != nil {
+		return nil, err
+	}
+	if !p.eof() {
+		return nil, p.errorf(p.pos, "unexpected %q", p.input[p.pos])
+	}
+	return list, nil
+}
+
+type parser struct {
+	input []rune
+	pos   int
+	// heredocEnd is the end of the here-document bodies following the current line.
+	heredocEnd int
+}
+
+func (p *parser) eof() bool {
+	return p.pos >= len(p.input)
+}
+
+func (p *parser) peek(offset int) rune {
+	if p.pos+offset >= len(p.input) {
+		return 0
+	}
+	return p.input[p.pos+offset]
+}
+
+func (p *parser) errorf(pos int, format string, args ...any) *SyntaxError {
+	return &SyntaxError{Column: pos + 1, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) incomplete(pos int, format string, args ...any) *SyntaxError {
+	err := p.errorf(pos, format, args...)
+	err.Incomplete = true
+	return err
+}
+
+// skipSpace skips blanks and a comment up to the end of the line.
+func (p *parser) skipSpace() {
+	for !p.eof() {
+		switch p.peek(0) {
+		case ' ', '\t', '\r':
+			p.pos++
+		case '#':
+			for !p.eof() && p.peek(0) != '\n' {
+				p.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+// operator returns the operator at the current position, empty if a word starts here.
+func (p *parser) operator() string {
+	switch c := p.peek(0); c {
+	case '|', '&':
+		if p.peek(1) == c {
+			return string([]rune{c, c})
+		}
+		return string(c)
+	case '>':
+		if p.peek(1) == '>' {
+			return ">>"
+		}
+		return ">"
+	case '<':
+		if p.peek(1) == '<' {
+			return "<<"
+		}
+		return "<"
+	case ';', '\n', '(', ')':
+		return string(c)
+	case '2':
+		switch {
+		case p.peek(1) == '>' && p.peek(2) == '>':
+			return "2>>"
+		case p.peek(1) == '>' && p.peek(2) == '&' && p.peek(3) == '1':
+			return "2>&1"
+		case p.peek(1) == '>':
+			return "2>"
+		}
+	}
+	return ""
+}
+
+func (p *parser) list(nested bool) (*List, error) {
+	list := &List{}
+	var op Op
+	for {
+		p.skipSpace()
+		if op == "" || op == OpSeq {
+			// empty commands are allowed between and after ";"
+			for p.operator() == ";" || p.operator() == "\n" {
+				p.separator()
+				p.skipSpace()
+			}
+		}
+		if p.eof() || (nested && p.peek(0) == ')') {
+			if op == OpAnd || op == OpOr {
+				return nil, p.incomplete(p.pos, "missing command after %q", op)
+			}
+			return list, nil
+		}
+		start := p.pos
+		pipeline, err := p.pipeline()
+		if err != nil {
+			return nil, err
+		}
+		if len(list.Items) == 0 {
+			op = ""
+		}
+		text := strings.TrimSpace(string(p.input[start:p.pos]))
+		list.Items = append(list.Items, Item{Op: op, Pipeline: *pipeline, Text: text})
+
+		p.skipSpace()
+		switch operator := p.operator(); operator {
+		case "&&", "||":
+			p.pos += 2
+			op = Op(operator)
+		case ";", "\n":
+			p.separator()
+			op = OpSeq
+		case "&":
+			p.pos++
+			list.Items[len(list.Items)-1].Background = true
+			op = OpSeq
+		case "":
+			return list, nil
+		case ")":
+			if nested {
+				return list, nil
+			}
+			return nil, p.errorf(p.pos, "unexpected %q", operator)
+		default:
+			return nil, p.errorf(p.pos, "unexpected %q", operator)
+		}
+	}
+}
+
+// separator consumes the ";" or the newline, skipping the here-document bodies following the line.
+func (p *parser) separator() {
+	newline := p.peek(0) == '\n'
+	p.pos++
+	if newline && p.heredocEnd > p.pos {
+		p.pos = p.heredocEnd
+	}
+}
+
+func (p *parser) pipeline() (*Pipeline, error) {
+	pipeline := &Pipeline{}
+	for {
+		command, err := p.command()
+		if err != nil {
+			return nil, err
+		}
+		pipeline.Commands = append(pipeline.Commands, *command)
+		p.skipSpace()
+		if p.operator() != "|" {
+			return pipeline, nil
+		}
+		p.pos++
+	}
+}
+
+func (p *parser) command() (*Command, error) {
+	p.skipSpace()
+	command := &Command{Pos: p.pos + 1}
+	for {
+		p.skipSpace()
+		if p.eof() {
+			break
+		}
+		operator := p.operator()
+		switch operator {
+		case "<", ">", ">>", "2>", "2>>":
+			redirect := Redirect{Fd: 1, Op: strings.TrimPrefix(operator, "2"), Pos: p.pos + 1}
+			switch {
+			case strings.HasPrefix(operator, "2"):
+				redirect.Fd = 2
+			case operator == "<":
+				redirect.Fd = 0
+			}
+			p.pos += len(operator)
+			p.skipSpace()
+			if p.eof() {
+				return nil, p.incomplete(p.pos, "missing file after %q", operator)
+			}
+			if p.operator() != "" {
+				return nil, p.errorf(p.pos, "missing file after %q", operator)
+			}
+			target, err := p.word()
+			if err != nil {
+				return nil, err
+			}
+			redirect.Target = *target
+			command.Redirects = append(command.Redirects, redirect)
+			continue
+		case "2>&1":
+			command.Redirects = append(command.Redirects, Redirect{Fd: 2, Op: ">&", Pos: p.pos + 1})
+			p.pos += len(operator)
+			continue
+		case "<<":
+			redirect, err := p.heredoc()
+			if err != nil {
+				return nil, err
+			}
+			command.Redirects = append(command.Redirects, *redirect)
+			continue
+		case "":
+			word, err := p.word()
+			if err != nil {
+				return nil, err
+			}
+			command.Words = append(command.Words, *word)
+			continue
+		}
+		break
+	}
+	if len(command.Words) == 0 && len(command.Redirects) == 0 {
+		if p.eof() {
+			return nil, p.incomplete(p.pos, "missing command")
+		}
+		return nil, p.errorf(p.pos, "unexpected %q", p.operator())
+	}
+	return command, nil
+}
+
+// heredoc parses "<<delimiter" and reads the body from the lines following the current one.
+// The body is expanded like a double quoted word, unless the delimiter is quoted.
+func (p *parser) heredoc() (*Redirect, error) {
+	redirect := &Redirect{Fd: 0, Op: "<<", Pos: p.pos + 1}
+	p.pos += 2
+	p.skipSpace()
+	start := p.pos
+	if p.eof() || p.operator() != "" {
+		return nil, p.errorf(p.pos, "missing here-document delimiter")
+	}
+	word, err := p.word()
+	if err != nil {
+		return nil, err
+	}
+	delimiter := ""
+	for _, part := range word.Parts {
+		if part.Kind != Literal {
+			return nil, p.errorf(start, "invalid here-document delimiter")
+		}
+		delimiter += part.Text
+	}
+	quoted := strings.ContainsAny(string(p.input[start:p.pos]), `'"\`)
+
+	// the body starts after the current line, or after the bodies of the previous here-documents
+	bodyStart := p.heredocEnd
+	if bodyStart <= p.pos {
+		bodyStart = p.pos
+		for bodyStart < len(p.input) && p.input[bodyStart] != '\n' {
+			bodyStart++
+		}
+		bodyStart++
+	}
+	var body []string
+	for pos := bodyStart; pos < len(p.input); {
+		end := pos
+		for end < len(p.input) && p.input[end] != '\n' {
+			end++
+		}
+		line := strings.TrimSuffix(string(p.input[pos:end]), "\r")
+		if line == delimiter {
+			p.heredocEnd = min(end+1, len(p.input))
+			if quoted {
+				redirect.Target = Word{Parts: []Part{{Kind: Literal, Text: joinLines(body)}}, Pos: bodyStart + 1}
+				return redirect, nil
+			}
+			target, err := expandBody(joinLines(body), bodyStart)
+			if err != nil {
+				return nil, err
+			}
+			redirect.Target = *target
+			return redirect, nil
+		}
+		body = append(body, line)
+		pos = end + 1
+	}
+	return nil, p.incomplete(redirect.Pos-1, "unterminated here-document, missing %q", delimiter)
+}
+
+func joinLines(lines []string) string {
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// expandBody parses the variables and substitutions of the body, \ escapes $, ` and itself.
+func expandBody(body string, offset int) (*Word, error) {
+	p := &parser{input: []rune(body)}
+	word := &Word{Pos: offset + 1}
+	literal := func(s string) {
+		if n := len(word.Parts); n > 0 && word.Parts[n-1].Kind == Literal {
+			word.Parts[n-1].Text += s
+			return
+		}
+		word.Parts = append(word.Parts, Part{Kind: Literal, Text: s})
+	}
+	for !p.eof() {
+		switch c := p.peek(0); c {
+		case '\\':
+			switch next := p.peek(1); next {
+			case '$', '`', '\\':
+				literal(string(next))
+				p.pos += 2
+			default:
+				literal(`\`)
+				p.pos++
+			}
+		case '$':
+			part, err := p.dollar()
+			if err != nil {
+				if syntaxErr, ok := err.(*SyntaxError); ok {
+					syntaxErr.Column += offset
+				}
+				return nil, err
+			}
+			if part == nil {
+				literal("$")
+				continue
+			}
+			word.Parts = append(word.Parts, *part)
+		default:
+			literal(string(c))
+			p.pos++
+		}
+	}
+	return word, nil
+}
+
+// word parses a word up to a blank or an operator outside of quotes.
+func (p *parser) word() (*Word, error) {
+	word := &Word{Pos: p.pos + 1}
+	literal := func(s string) {
+		if n := len(word.Parts); n > 0 && word.Parts[n-1].Kind == Literal {
+			word.Parts[n-1].Text += s
+			return
+		}
+		word.Parts = append(word.Parts, Part{Kind: Literal, Text: s})
+	}
+	if c := p.peek(0); c == '{' || c == '[' {
+		text, err := p.bracketed()
+		if err != nil {
+			return nil, err
+		}
+		literal(text)
+	}
+	for !p.eof() {
+		switch c := p.peek(0); c {
+		case ' ', '\t', '\r', '\n', '|', '&', ';', '<', '>', '(', ')':
+			if c == '(' {
+				return nil, p.errorf(p.pos, "unexpected %q", c)
+			}
+			return word, nil
+		case '\'':
+			start := p.pos
+			p.pos++
+			end := p.pos
+			for end < len(p.input) && p.input[end] != '\'' {
+				end++
+			}
+			if end >= len(p.input) {
+				return nil, p.incomplete(start, "unterminated single quote")
+			}
+			literal(string(p.input[p.pos:end]))
+			p.pos = end + 1
+		case '"':
+			start := p.pos
+			p.pos++
+			literal("")
+			for {
+				if p.eof() {
+					return nil, p.incomplete(start, "unterminated double quote")
+				}
+				c := p.peek(0)
+				if c == '"' {
+					p.pos++
+					break
+				}
+				switch c {
+				case '\\':
+					switch next := p.peek(1); next {
+					case '"', '\\', '$', '`':
+						literal(string(next))
+						p.pos += 2
+					case '\n':
+						p.pos += 2
+					default:
+						literal(`\`)
+						p.pos++
+					}
+				case '$':
+					part, err := p.dollar()
+					if err != nil {
+						return nil, err
+					}
+					if part == nil {
+						literal("$")
+						continue
+					}
+					word.Parts = append(word.Parts, *part)
+				default:
+					literal(string(c))
+					p.pos++
+				}
+			}
+		case '\\':
+			if p.pos+1 >= len(p.input) {
+				return nil, p.incomplete(p.pos, "unexpected end of line after \\")
+			}
+			if next := p.peek(1); next != '\n' {
+				literal(string(next))
+			}
+			p.pos += 2
+		case '$':
+			part, err := p.dollar()
+			if err != nil {
+				return nil, err
+			}
+			if part == nil {
+				literal("$")
+				continue
+			}
+			word.Parts = append(word.Parts, *part)
+		default:
+			literal(string(c))
+			p.pos++
+		}
+	}
+	return word, nil
+}
+
+// bracketed returns the text up to the bracket matching the one at the current position,
+// the brackets within json strings are skipped.
+func (p *parser) bracketed() (string, error) {
+	start := p.pos
+	var stack []rune
+	for !p.eof() {
+		c := p.peek(0)
+		p.pos++
+		switch c {
+		case '{':
+			stack = append(stack, '}')
+		case '[':
+			stack = append(stack, ']')
+		case '}', ']':
+			if c != stack[len(stack)-1] {
+				return "", p.errorf(p.pos-1, "unexpected %q", c)
+			}
+			stack = stack[:len(stack)-1]
+			if len(stack) == 0 {
+				return string(p.input[start:p.pos]), nil
+			}
+		case '"':
+			for !p.eof() && p.peek(0) != '"' {
+				if p.peek(0) == '\\' {
+					p.pos++
+				}
+				p.pos++
+			}
+			if p.eof() {
+				return "", p.incomplete(start, "unterminated %q", p.input[start])
+			}
+			p.pos++
+		}
+	}
+	return "", p.incomplete(start, "unterminated %q", p.input[start])
+}
+
+// dollar parses $name, ${name}, the special $0..$9, $#, $@, $*, $? and a $(...) substitution.
+// It returns nil and consumes the $ if no expansion follows.
+func (p *parser) dollar() (*Part, error) {
+	start := p.pos
+	p.pos++
+	switch c := p.peek(0); {
+	case c == '(':
+		p.pos++
+		list, err := p.list(true)
+		if err != nil {
+			return nil, err
+		}
+		if p.peek(0) != ')' {
+			return nil, p.incomplete(start, "unterminated command substitution")
+		}
+		p.pos++
+		return &Part{Kind: Substitution, Subst: list}, nil
+	case c == '{':
+		end := p.pos + 1
+		for end < len(p.input) && p.input[end] != '}' {
+			end++
+		}
+		if end >= len(p.input) {
+			return nil, p.incomplete(start, "unterminated ${")
+		}
+		name := string(p.input[p.pos+1 : end])
+		if name == "" {
+			return nil, p.errorf(start, "empty variable name")
+		}
+		p.pos = end + 1
+		return &Part{Kind: Variable, Text: name}, nil
+	case c >= '0' && c <= '9', c == '#', c == '@', c == '*', c == '?':
+		p.pos++
+		return &Part{Kind: Variable, Text: string(c)}, nil
+	case isNameStart(c):
+		end := p.pos
+		for end < len(p.input) && isName(p.input[end]) {
+			end++
+		}
+		name := string(p.input[p.pos:end])
+		p.pos = end
+		return &Part{Kind: Variable, Text: name}, nil
+	}
+	return nil, nil
+}
+
+func isNameStart(c rune) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isName(c rune) bool {
+	return isNameStart(c) || (c >= '0' && c <= '9')
+}
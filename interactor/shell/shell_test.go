@@ -0,0 +1,174 @@
+package shell
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// format renders the list in a compact form: the words are quoted, the variables are ${name},
+// the substitutions $(list) and the redirections fd op target.
+func format(list *List) string {
+	var items []string
+	for _, item := range list.Items {
+		var commands []string
+		for _, command := range item.Pipeline.Commands {
+			var fields []string
+			for _, word := range command.Words {
+				fields = append(fields, formatWord(word))
+			}
+			for _, redirect := range command.Redirects {
+				field := fmt.Sprintf("%d%s", redirect.Fd, redirect.Op)
+				if len(redirect.Target.Parts) > 0 {
+					field += formatWord(redirect.Target)
+				}
+				fields = append(fields, field)
+			}
+			commands = append(commands, strings.Join(fields, " "))
+		}
+		s := strings.Join(commands, " | ")
+		if item.Op != "" {
+			s = string(item.Op) + " " + s
+		}
+		if item.Background {
+			s += " &"
+		}
+		items = append(items, s)
+	}
+	return strings.Join(items, " ")
+}
+
+func formatWord(word Word) string {
+	var b strings.Builder
+	for _, part := range word.Parts {
+		switch part.Kind {
+		case Literal:
+			b.WriteString(part.Text)
+		case Variable:
+			b.WriteString("${" + part.Text + "}")
+		case Substitution:
+			b.WriteString("$(" + format(part.Subst) + ")")
+		}
+	}
+	return fmt.Sprintf("%q", b.String())
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"empty", "", ""},
+		{"blank", "  \t ", ""},
+		{"comment", "# nothing", ""},
+		{"words", "tool echo text=hi", `"tool" "echo" "text=hi"`},
+		{"trailing comment", "tools # list", `"tools"`},
+		{"single quotes", `echo 'a b' '$x' '"'`, `"echo" "a b" "$x" "\""`},
+		{"double quotes", `echo "a b" "\"q\"" "\$x" "a\b"`, `"echo" "a b" "\"q\"" "$x" "a\\b"`},
+		{"empty quotes", `echo "" ''`, `"echo" "" ""`},
+		{"adjacent quotes", `echo a'b'"c"`, `"echo" "abc"`},
+		{"escapes", `echo a\ b \; \|`, `"echo" "a b" ";" "|"`},
+		{"line continuation", "echo a\\\nb", `"echo" "ab"`},
+		{"json object", `tool echo {"text": "a b", "n": [1, 2]}`, `"tool" "echo" "{\"text\": \"a b\", \"n\": [1, 2]}"`},
+		{"json brackets in strings", `echo {"a": "}]"}`, `"echo" "{\"a\": \"}]\"}"`},
+		{"json array", `echo [1, {"a": 2}]x`, `"echo" "[1, {\"a\": 2}]x"`},
+		{"variables", `echo $a ${b}c $1 $# $@ $? "$a-x"`, `"echo" "${a}" "${b}c" "${1}" "${#}" "${@}" "${?}" "${a}-x"`},
+		{"lone dollar", `echo $ a$`, `"echo" "$" "a$"`},
+		{"substitution", `echo $(tools | count) "x$(a; b)"`, `"echo" "$(\"tools\" | \"count\")" "x$(\"a\" ; \"b\")"`},
+		{"nested substitution", `echo $(a $(b))`, `"echo" "$(\"a\" \"$(\\\"b\\\")\")"`},
+		{"pipeline", "tools | grep x | head", `"tools" | "grep" "x" | "head"`},
+		{"sequence", "a; b ; c", `"a" ; "b" ; "c"`},
+		{"newlines", "a\nb\n\nc\n", `"a" ; "b" ; "c"`},
+		{"empty commands", ";; a;;", `"a"`},
+		{"and or", "a && b || c", `"a" && "b" || "c"`},
+		{"background", "a & b && c &", `"a" & ; "b" && "c" &`},
+		{"redirects", "a < in > out 2> err", `"a" 0<"in" 1>"out" 2>"err"`},
+		{"append", "a >> out 2>>err", `"a" 1>>"out" 2>>"err"`},
+		{"stderr to stdout", "a 2>&1 | b", `"a" 2>& | "b"`},
+		{"digit word", "echo 2 23", `"echo" "2" "23"`},
+		{"heredoc", "cat <<EOF\nhello $name\n$(x)\nEOF", `"cat" 0<<"hello ${name}\n$(\"x\")\n"`},
+		{"heredoc quoted", "cat <<'EOF'\nhello $name\nEOF", `"cat" 0<<"hello $name\n"`},
+		{"heredoc escapes", "cat <<EOF\n\\$a \\b\nEOF", `"cat" 0<<"$a \\b\n"`},
+		{"heredoc empty", "cat <<EOF\nEOF", `"cat" 0<<`},
+		{"heredoc then command", "cat <<EOF; b\nx\nEOF\nc", `"cat" 0<<"x\n" ; "b" ; "c"`},
+		{"two heredocs", "a <<A; b <<B\n1\nA\n2\nB", `"a" 0<<"1\n" ; "b" 0<<"2\n"`},
+		{"heredoc crlf", "cat <<EOF\r\nx\r\nEOF\r\n", `"cat" 0<<"x\n"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse(%q) error: %v", tt.input, err)
+			}
+			if got := format(list); got != tt.want {
+				t.Errorf("Parse(%q)\n got: %s\nwant: %s", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseText(t *testing.T) {
+	list, err := Parse("a x | b && c;  d &")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var texts []string
+	for _, item := range list.Items {
+		texts = append(texts, item.Text)
+	}
+	if got, want := strings.Join(texts, ","), "a x | b,c,d"; got != want {
+		t.Errorf("texts = %q, want %q", got, want)
+	}
+}
+
+func TestParseError(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		column     int
+		incomplete bool
+	}{
+		{"single quote", `echo 'abc`, 6, true},
+		{"double quote", `echo "abc`, 6, true},
+		{"substitution", `echo $(tools`, 6, true},
+		{"substitution in quotes", `echo "$(tools`, 7, true},
+		{"quote in substitution", `echo "$(tools"`, 14, true},
+		{"brace variable", `echo ${abc`, 6, true},
+		{"json", `echo {"a": 1`, 6, true},
+		{"json string", `echo {"a`, 6, true},
+		{"trailing backslash", `echo \`, 6, true},
+		{"and", "a &&", 5, true},
+		{"or", "a || ", 6, true},
+		{"pipe", "a |", 4, true},
+		{"redirect", "a >", 4, true},
+		{"heredoc", "cat <<EOF\nhello", 5, true},
+		{"heredoc without body", "cat <<EOF", 5, true},
+		{"leading pipe", "| a", 1, false},
+		{"double pipe", "a | | b", 5, false},
+		{"leading and", "&& a", 1, false},
+		{"unexpected paren", "a )", 3, false},
+		{"paren in word", "a(b", 2, false},
+		{"redirect operator", "a > | b", 5, false},
+		{"empty variable", "echo ${}", 6, false},
+		{"json mismatch", `echo {"a": 1]`, 13, false},
+		{"heredoc delimiter", "cat << ;", 8, false},
+		{"heredoc variable delimiter", "cat <<$x\n$x", 7, false},
+		{"heredoc body", "cat <<EOF\n${}\nEOF", 11, false},
+		{"after substitution", "echo $(a))", 10, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.input)
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("Parse(%q) error = %v, want a SyntaxError", tt.input, err)
+			}
+			if syntaxErr.Column != tt.column || syntaxErr.Incomplete != tt.incomplete {
+				t.Errorf("Parse(%q) = %v (incomplete %v), want column %d (incomplete %v)",
+					tt.input, syntaxErr, syntaxErr.Incomplete, tt.column, tt.incomplete)
+			}
+		})
+	}
+}
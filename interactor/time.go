@@ -0,0 +1,21 @@
+package interactor
+
+import (
+	"context"
+
+	"github.com/cherrydra/mcpurl/parser"
+	"github.com/cherrydra/mcpurl/stats"
+)
+
+// time runs the command and reports its timing and traffic to stderr as a json line.
+func (ia *Interactor) time(ctx context.Context, args []string, std stdio) error {
+	if len(args) == 0 {
+		return parser.ErrInvalidUsage
+	}
+	s := stats.Start()
+	err := ia.runWords(stats.NewContext(ctx, s), args, std)
+	if reportErr := s.Report(std.err); err == nil {
+		err = reportErr
+	}
+	return err
+}
@@ -0,0 +1,183 @@
+package interactor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/cherrydra/mcpurl/interactor/shell"
+	"github.com/cherrydra/mcpurl/parser"
+	"github.com/tidwall/gjson"
+)
+
+// resultVar holds the output of the previous command line printed to the terminal.
+const resultVar = "_"
+
+// variable returns the value of the variable, name can be followed by a gjson path
+// extracting a field of the value, json lines values are queried as an array.
+func (ia *Interactor) variable(name string) (string, bool) {
+	name, path, _ := strings.Cut(name, ".")
+	ia.mu.Lock()
+	value, ok := ia.vars[name]
+	ia.mu.Unlock()
+	if !ok || path == "" {
+		return value, ok
+	}
+	result := query(jsonValue(value), path)
+	if result.Type == gjson.String {
+		return result.String(), true
+	}
+	return result.Raw, true
+}
+
+// query gets the gjson path of the document, descending into the json encoded strings
+// such as the text content of a tool result.
+func query(doc, path string) gjson.Result {
+	if result := gjson.Get(doc, path); result.Exists() {
+		return result
+	}
+	segments := strings.Split(path, ".")
+	for i := len(segments) - 1; i > 0; i-- {
+		result := gjson.Get(doc, strings.Join(segments[:i], "."))
+		if result.Type == gjson.String && gjson.Valid(result.String()) {
+			return query(result.String(), strings.Join(segments[i:], "."))
+		}
+	}
+	return gjson.Result{}
+}
+
+// jsonValue returns the value as a json document, json lines are joined to an array.
+func jsonValue(value string) string {
+	if gjson.Valid(value) {
+		return value
+	}
+	lines := strings.Split(strings.TrimSpace(value), "\n")
+	for _, line := range lines {
+		if !gjson.Valid(line) {
+			return value
+		}
+	}
+	return "[" + strings.Join(lines, ",") + "]"
+}
+
+func (ia *Interactor) setVariable(name, value string) {
+	ia.mu.Lock()
+	defer ia.mu.Unlock()
+	if ia.vars == nil {
+		ia.vars = map[string]string{}
+	}
+	ia.vars[name] = value
+}
+
+// assignment returns the variable name and the pipeline of "set name = <command>".
+func assignment(pipeline shell.Pipeline) (string, shell.Pipeline, bool) {
+	words := pipeline.Commands[0].Words
+	if len(words) < 3 || literal(words[0]) != "set" || literal(words[2]) != "=" {
+		return "", pipeline, false
+	}
+	name := literal(words[1])
+	if name == "" || strings.ContainsAny(name, ".=$") {
+		return "", pipeline, false
+	}
+	commands := append([]shell.Command{pipeline.Commands[0]}, pipeline.Commands[1:]...)
+	commands[0].Words = words[3:]
+	return name, shell.Pipeline{Commands: commands}, true
+}
+
+var assignmentPrefix = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*=`)
+
+// plainAssignment returns the variable name and the value word of "name=value".
+func plainAssignment(pipeline shell.Pipeline) (string, shell.Word, bool) {
+	words := pipeline.Commands[0].Words
+	if len(pipeline.Commands) != 1 || len(words) != 1 || len(pipeline.Commands[0].Redirects) > 0 {
+		return "", shell.Word{}, false
+	}
+	parts := words[0].Parts
+	if len(parts) == 0 || parts[0].Kind != shell.Literal {
+		return "", shell.Word{}, false
+	}
+	prefix := assignmentPrefix.FindString(parts[0].Text)
+	if prefix == "" {
+		return "", shell.Word{}, false
+	}
+	value := shell.Word{Parts: append([]shell.Part{{Kind: shell.Literal, Text: parts[0].Text[len(prefix):]}}, parts[1:]...)}
+	return prefix[:len(prefix)-1], value, true
+}
+
+// literal returns the text of a word without expansions, empty otherwise.
+func literal(word shell.Word) string {
+	if len(word.Parts) != 1 || word.Parts[0].Kind != shell.Literal {
+		return ""
+	}
+	return word.Parts[0].Text
+}
+
+// assign runs the pipeline and stores its output without the trailing newlines in the variable.
+func (ia *Interactor) assign(ctx context.Context, name string, pipeline shell.Pipeline, std stdio) error {
+	if len(pipeline.Commands[0].Words) == 0 {
+		return parser.ErrInvalidUsage
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		return fmt.Errorf("create pipe: %w", err)
+	}
+	defer r.Close()
+	errChan := make(chan error, 1)
+	go func() {
+		defer w.Close()
+		errChan <- ia.runPipeline(ctx, pipeline, stdio{std.in, w, std.err})
+	}()
+	out, readErr := io.ReadAll(r)
+	if err := <-errChan; err != nil {
+		return err
+	}
+	if readErr != nil {
+		return fmt.Errorf("read output: %w", readErr)
+	}
+	ia.setVariable(name, strings.TrimRight(string(out), "\n"))
+	return nil
+}
+
+// showVariables prints the variables as a json object.
+func (ia *Interactor) showVariables(out io.Writer) error {
+	ia.mu.Lock()
+	defer ia.mu.Unlock()
+	vars := map[string]string{}
+	for name, value := range ia.vars {
+		vars[name] = value
+	}
+	return json.NewEncoder(out).Encode(vars)
+}
+
+func (ia *Interactor) unsetVariables(names []string) error {
+	if len(names) == 0 {
+		return parser.ErrInvalidUsage
+	}
+	ia.mu.Lock()
+	defer ia.mu.Unlock()
+	for _, name := range names {
+		delete(ia.vars, name)
+	}
+	return nil
+}
+
+// teeResult copies the output to the terminal and keeps it as the result of the command line.
+type teeResult struct {
+	buf  bytes.Buffer
+	done chan struct{}
+}
+
+func (ia *Interactor) tee(r io.ReadCloser) *teeResult {
+	t := &teeResult{done: make(chan struct{})}
+	go func() {
+		defer close(t.done)
+		defer r.Close()
+		_, _ = io.Copy(io.MultiWriter(os.Stdout, &t.buf), r)
+	}()
+	return t
+}
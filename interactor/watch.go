@@ -0,0 +1,173 @@
+package interactor
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cherrydra/mcpurl/interactor/shell"
+	"github.com/cherrydra/mcpurl/parser"
+)
+
+// runWords runs the command given as arguments, a single argument is parsed as a command line
+// so that pipelines and lists can be quoted.
+func (ia *Interactor) runWords(ctx context.Context, words []string, std stdio) error {
+	if len(words) > 1 {
+		return ia.exec(ctx, words[0], words[1:], std)
+	}
+	list, err := shell.Parse(words[0])
+	if err != nil {
+		return fmt.Errorf("%s: %v", words[0], err)
+	}
+	return ia.runList(ctx, list, std)
+}
+
+// watch re-runs the command every interval until interrupted, redrawing its output.
+// --diff highlights the characters changed since the previous run.
+func (ia *Interactor) watch(ctx context.Context, args []string, std stdio) error {
+	interval, diff := 2*time.Second, false
+	for len(args) > 0 && strings.HasPrefix(args[0], "-") {
+		switch args[0] {
+		case "-n", "--interval":
+			if len(args) < 2 {
+				return parser.ErrInvalidUsage
+			}
+			seconds, err := strconv.ParseFloat(args[1], 64)
+			if err != nil || seconds <= 0 {
+				return fmt.Errorf("invalid interval: %s", args[1])
+			}
+			interval = time.Duration(seconds * float64(time.Second))
+			args = args[1:]
+		case "-d", "--diff":
+			diff = true
+		default:
+			return fmt.Errorf("unknown option: %s", args[0])
+		}
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		return parser.ErrInvalidUsage
+	}
+
+	command := strings.Join(args, " ")
+	var previous string
+	for {
+		output, err := ia.capture(ctx, args)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil && !errors.As(err, new(reportedError)) {
+			output += fmt.Sprintf("Error: %v\n", err)
+		}
+		fmt.Fprint(std.out, "\033[H\033[2J")
+		fmt.Fprintf(std.out, "Every %v: %s    %s\n\n", interval, command, time.Now().Format(time.DateTime))
+		if diff && previous != "" {
+			fmt.Fprint(std.out, highlightChanges(previous, output))
+		} else {
+			fmt.Fprint(std.out, output)
+		}
+		previous = output
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
+
+// capture runs the command and returns its output and error output.
+func (ia *Interactor) capture(ctx context.Context, words []string) (string, error) {
+	devNull, err := os.Open(os.DevNull)
+	if err != nil {
+		return "", fmt.Errorf("open %s: %w", os.DevNull, err)
+	}
+	defer devNull.Close()
+	r, w, err := os.Pipe()
+	if err != nil {
+		return "", fmt.Errorf("create pipe: %w", err)
+	}
+	defer r.Close()
+	errChan := make(chan error, 1)
+	go func() {
+		defer w.Close()
+		errChan <- ia.runWords(ctx, words, stdio{devNull, w, w})
+	}()
+	out, readErr := io.ReadAll(r)
+	if err := <-errChan; err != nil {
+		return string(out), err
+	}
+	if readErr != nil {
+		return string(out), fmt.Errorf("read output: %w", readErr)
+	}
+	return string(out), nil
+}
+
+// highlightChanges returns the output with the characters differing from the previous output
+// at the same line and column in reverse video.
+func highlightChanges(previous, output string) string {
+	prevLines := strings.Split(previous, "\n")
+	var b strings.Builder
+	for i, line := range strings.Split(output, "\n") {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		var prev []rune
+		if i < len(prevLines) {
+			prev = []rune(prevLines[i])
+		}
+		highlighted := false
+		for j, r := range []rune(line) {
+			changed := j >= len(prev) || prev[j] != r
+			if changed != highlighted {
+				if changed {
+					b.WriteString("\033[7m")
+				} else {
+					b.WriteString("\033[0m")
+				}
+				highlighted = changed
+			}
+			b.WriteRune(r)
+		}
+		if highlighted {
+			b.WriteString("\033[0m")
+		}
+	}
+	return b.String()
+}
+
+// repeat runs the command n times, the failed runs are reported without stopping.
+func (ia *Interactor) repeat(ctx context.Context, args []string, std stdio) error {
+	if len(args) < 2 {
+		return parser.ErrInvalidUsage
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 1 {
+		return fmt.Errorf("invalid count: %s", args[0])
+	}
+	failed := 0
+	for i := 1; i <= n; i++ {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := ia.runWords(ctx, args[1:], std); err != nil {
+			if ctx.Err() != nil || errors.Is(err, os.ErrProcessDone) {
+				return cmp.Or(ctx.Err(), err)
+			}
+			failed++
+			if !errors.As(err, new(reportedError)) {
+				fmt.Fprintf(std.err, "Error: run %d: %v\n", i, err)
+			}
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d runs failed", failed, n)
+	}
+	return nil
+}
@@ -116,6 +116,13 @@ func (m *TalkContextManager) Current() *TalkContext {
 	return m.contexts[m.current]
 }
 
+// CurrentIndex returns the index of the current context.
+func (m *TalkContextManager) CurrentIndex() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.current
+}
+
 // List returns a list of TalkContextInfo for all contexts, including the current one.
 func (m *TalkContextManager) List() []*TalkContextInfo {
 	m.mu.RLock()
@@ -8,9 +8,11 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"time"
 
 	"github.com/cherrydra/mcpurl/interactor/spinner"
 	"github.com/cherrydra/mcpurl/mcp/features"
+	"github.com/cherrydra/mcpurl/stats"
 	"github.com/openai/openai-go"
 )
 
@@ -62,6 +64,8 @@ func (i *LLM) Msg(ctx context.Context, f features.ServerFeatures, message string
 
 	for {
 		s := spinner.Spin(ctx, "", out, false)
+		start := time.Now()
+		var firstToken time.Duration
 		stream := i.Client.Chat.Completions.NewStreaming(ctx, params)
 		acc := openai.ChatCompletionAccumulator{}
 		detector := &LastByteDetector{}
@@ -70,6 +74,9 @@ func (i *LLM) Msg(ctx context.Context, f features.ServerFeatures, message string
 				s.Stop()
 				return fmt.Errorf("streaming error: %w", stream.Err())
 			}
+			if firstToken == 0 {
+				firstToken = time.Since(start)
+			}
 			chunk := stream.Current()
 			acc.AddChunk(chunk)
 			if chunk.Choices[0].Delta.Content != "" {
@@ -78,6 +85,7 @@ func (i *LLM) Msg(ctx context.Context, f features.ServerFeatures, message string
 			fmt.Fprint(io.MultiWriter(out, detector), chunk.Choices[0].Delta.Content)
 		}
 		s.Stop()
+		stats.FromContext(ctx).LLM(firstToken, time.Since(start))
 
 		if detector.TotalBytes() > 0 && detector.LastByte() != '\n' {
 			fmt.Fprintln(out)
@@ -99,8 +107,20 @@ func (i *LLM) Msg(ctx context.Context, f features.ServerFeatures, message string
 			}
 			for _, toolCall := range acc.Choices[0].Message.ToolCalls {
 				s := spinner.Spin(ctx, fmt.Sprintf("\033[90m%s\033[0m\n", toolCall.Function.Name), out, true)
+				start := time.Now()
 				result, err := f.CallTool2(ctx, toolCall.Function.Name, toolCall.Function.Arguments)
+				stats.FromContext(ctx).Tool(time.Since(start))
 				s.Stop()
+				// let the model correct its arguments
+				var validationErr *features.ValidationError
+				if errors.As(err, &validationErr) {
+					params.Messages = append(params.Messages, openai.ToolMessage(validationErr.Error(), toolCall.ID))
+					continue
+				}
+				if errors.Is(err, features.ErrNotConfirmed) {
+					params.Messages = append(params.Messages, openai.ToolMessage("The user declined to call this tool.", toolCall.ID))
+					continue
+				}
 				if err != nil {
 					return fmt.Errorf("call tool %s: %w", toolCall.Function.Name, err)
 				}
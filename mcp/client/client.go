@@ -1,6 +1,11 @@
 package client
 
 import (
+	"context"
+	"sync"
+
+	"github.com/cherrydra/mcpurl/mcp/features"
+	"github.com/cherrydra/mcpurl/stats"
 	"github.com/cherrydra/mcpurl/version"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -11,4 +16,60 @@ var (
 		Title:   "Command Line General AI Agent",
 		Version: version.Short(),
 	}
+
+	// initializations holds the initialize handshake of every live session, keyed by *mcp.ClientSession.
+	initializations sync.Map
 )
+
+// Initialization is the initialize handshake of a session.
+type Initialization struct {
+	Params *mcp.InitializeParams
+	Result *mcp.InitializeResult
+}
+
+// Initialized returns the initialize handshake of the session, nil if unknown.
+func Initialized(session *mcp.ClientSession) *Initialization {
+	if v, ok := initializations.Load(session); ok {
+		return v.(*Initialization)
+	}
+	return nil
+}
+
+// Connect connects to the mcp server over the given transport.
+// The cached server listings of the session are invalidated on list changed notifications,
+// and the timing and traffic of the session are recorded to the stats of the context.
+func Connect(ctx context.Context, t mcp.Transport) (*mcp.ClientSession, error) {
+	client := mcp.NewClient(Implementation, &mcp.ClientOptions{
+		ToolListChangedHandler: func(_ context.Context, cs *mcp.ClientSession, _ *mcp.ToolListChangedParams) {
+			features.ServerFeatures{Session: cs}.InvalidateTools()
+		},
+		PromptListChangedHandler: func(_ context.Context, cs *mcp.ClientSession, _ *mcp.PromptListChangedParams) {
+			features.ServerFeatures{Session: cs}.InvalidatePrompts()
+		},
+		ResourceListChangedHandler: func(_ context.Context, cs *mcp.ClientSession, _ *mcp.ResourceListChangedParams) {
+			features.ServerFeatures{Session: cs}.InvalidateResources()
+		},
+	})
+	client.AddSendingMiddleware(recordInitialization, stats.Middleware)
+	return client.Connect(ctx, stats.Transport(t))
+}
+
+func recordInitialization(next mcp.MethodHandler[*mcp.ClientSession]) mcp.MethodHandler[*mcp.ClientSession] {
+	return func(ctx context.Context, cs *mcp.ClientSession, method string, params mcp.Params) (mcp.Result, error) {
+		result, err := next(ctx, cs, method, params)
+		if method != "initialize" || err != nil {
+			return result, err
+		}
+		p, _ := params.(*mcp.InitializeParams)
+		r, _ := result.(*mcp.InitializeResult)
+		initializations.Store(cs, &Initialization{Params: p, Result: r})
+		return result, err
+	}
+}
+
+// Close closes the session and releases its cached server listings.
+func Close(session *mcp.ClientSession) error {
+	defer initializations.Delete(session)
+	defer features.ServerFeatures{Session: session}.Forget()
+	return session.Close()
+}
@@ -1,9 +1,50 @@
 package features
 
 import (
+	"cmp"
 	"errors"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
 var (
-	ErrNoSession = errors.New("no session")
+	ErrNoSession    = errors.New("no session")
+	ErrNotConfirmed = errors.New("tool call not confirmed, use --yes to skip confirmation")
 )
+
+// Destructive reports whether the tool may perform destructive updates.
+// The hints missing from the annotations take their defaults of the spec: not read-only and destructive.
+func Destructive(tool *mcp.Tool) bool {
+	a := cmp.Or(tool.Annotations, &mcp.ToolAnnotations{})
+	return !a.ReadOnlyHint && (a.DestructiveHint == nil || *a.DestructiveHint)
+}
+
+// Hints returns the human readable behavior hints of the tool annotations, with the defaults of the spec.
+func Hints(tool *mcp.Tool) []string {
+	a := cmp.Or(tool.Annotations, &mcp.ToolAnnotations{})
+	var hints []string
+	if a.ReadOnlyHint {
+		hints = append(hints, "read-only")
+	}
+	if Destructive(tool) {
+		hints = append(hints, "destructive")
+	}
+	if a.IdempotentHint {
+		hints = append(hints, "idempotent")
+	}
+	if a.OpenWorldHint == nil || *a.OpenWorldHint {
+		hints = append(hints, "open-world")
+	}
+	return hints
+}
+
+// Title returns the display name of the tool.
+func Title(tool *mcp.Tool) string {
+	if tool.Title != "" {
+		return tool.Title
+	}
+	if tool.Annotations != nil && tool.Annotations.Title != "" {
+		return tool.Annotations.Title
+	}
+	return tool.Name
+}
@@ -0,0 +1,99 @@
+package features
+
+import (
+	"sync"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+var (
+	// caches holds the server listings of every live session, keyed by *mcp.ClientSession.
+	caches sync.Map
+)
+
+type listCache struct {
+	tools     cachedList[*mcp.Tool]
+	prompts   cachedList[*mcp.Prompt]
+	resources cachedList[*mcp.Resource]
+	templates cachedList[*mcp.ResourceTemplate]
+}
+
+// cachedList is a server listing, the lock is not held while listing so that invalidations never wait
+// for the server. A listing started before an invalidation is returned but not cached.
+type cachedList[T any] struct {
+	mu         sync.Mutex
+	items      []T
+	cached     bool
+	generation uint64
+}
+
+func (l *cachedList[T]) get(list func() ([]T, error)) ([]T, error) {
+	l.mu.Lock()
+	if l.cached {
+		defer l.mu.Unlock()
+		return l.items, nil
+	}
+	generation := l.generation
+	l.mu.Unlock()
+
+	items, err := list()
+	if err != nil {
+		return nil, err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.generation == generation {
+		l.items, l.cached = items, true
+	}
+	return items, nil
+}
+
+func (l *cachedList[T]) invalidate() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.items, l.cached = nil, false
+	l.generation++
+}
+
+func (s ServerFeatures) cache() *listCache {
+	c, _ := caches.LoadOrStore(s.Session, &listCache{})
+	return c.(*listCache)
+}
+
+// invalidate drops a listing of the session, the sessions without cache are left alone
+// so that late notifications do not recreate the cache of a forgotten session.
+func (s ServerFeatures) invalidate(drop func(c *listCache)) {
+	if c, ok := caches.Load(s.Session); ok {
+		drop(c.(*listCache))
+	}
+}
+
+// InvalidateTools drops the cached tool listing of the session.
+func (s ServerFeatures) InvalidateTools() {
+	s.invalidate(func(c *listCache) { c.tools.invalidate() })
+}
+
+// InvalidatePrompts drops the cached prompt listing of the session.
+func (s ServerFeatures) InvalidatePrompts() {
+	s.invalidate(func(c *listCache) { c.prompts.invalidate() })
+}
+
+// InvalidateResources drops the cached resource and resource template listings of the session.
+func (s ServerFeatures) InvalidateResources() {
+	s.invalidate(func(c *listCache) {
+		c.resources.invalidate()
+		c.templates.invalidate()
+	})
+}
+
+// Refresh drops all cached listings of the session, the next list call goes to the server.
+func (s ServerFeatures) Refresh() {
+	s.InvalidateTools()
+	s.InvalidatePrompts()
+	s.InvalidateResources()
+}
+
+// Forget releases the cache of a session, it should be called once the session is closed.
+func (s ServerFeatures) Forget() {
+	caches.Delete(s.Session)
+}
@@ -5,7 +5,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"os"
+	"slices"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -13,6 +15,11 @@ import (
 type ServerFeatures struct {
 	Session *mcp.ClientSession
 	Out     *os.File
+
+	// NoValidate skips validating tool arguments and structured content against the tool schemas.
+	NoValidate bool
+	// Confirm asks the user before calling a destructive tool, destructive tools are refused if nil.
+	Confirm func(prompt string) (bool, error)
 }
 
 func (s ServerFeatures) CallTool(ctx context.Context, tool, data string) error {
@@ -29,6 +36,10 @@ func (s ServerFeatures) CallTool1(ctx context.Context, tool string, params map[s
 	if s.Session == nil {
 		return ErrNoSession
 	}
+	t, err := s.prepareCall(ctx, tool, params)
+	if err != nil {
+		return err
+	}
 	result, err := s.Session.CallTool(ctx, &mcp.CallToolParams{
 		Name:      tool,
 		Arguments: params,
@@ -41,7 +52,7 @@ func (s ServerFeatures) CallTool1(ctx context.Context, tool string, params map[s
 		out, _ := c.MarshalJSON()
 		fmt.Fprintln(cmp.Or(s.Out, os.Stdout), string(out))
 	}
-	return nil
+	return s.validateStructuredContent(t, result)
 }
 
 func (s ServerFeatures) CallTool2(ctx context.Context, tool string, arguments string) (mcp.Content, error) {
@@ -54,6 +65,10 @@ func (s ServerFeatures) CallTool2(ctx context.Context, tool string, arguments st
 			return nil, fmt.Errorf("unmarshal tool arguments: %w", err)
 		}
 	}
+	t, err := s.prepareCall(ctx, tool, params)
+	if err != nil {
+		return nil, err
+	}
 	result, err := s.Session.CallTool(ctx, &mcp.CallToolParams{
 		Name:      tool,
 		Arguments: params,
@@ -61,10 +76,71 @@ func (s ServerFeatures) CallTool2(ctx context.Context, tool string, arguments st
 	if err != nil {
 		return nil, fmt.Errorf("call tool: %w", err)
 	}
+	if err := s.validateStructuredContent(t, result); err != nil {
+		slog.Warn("Tool returned invalid structured content", "tool", tool, "error", err)
+	}
 
 	return result.Content[0], nil
 }
 
+// prepareCall validates the arguments against the input schema of the tool and asks for confirmation
+// if the tool is destructive, the tool is returned for validating its result.
+// A tool is destructive unless its annotations tell otherwise, so a tool whose annotations are unknown,
+// because the tools could not be listed or the tool is not listed, is confirmed too.
+func (s ServerFeatures) prepareCall(ctx context.Context, tool string, params map[string]any) (*mcp.Tool, error) {
+	tools, err := s.ListTools(ctx)
+	if err != nil {
+		slog.Debug("Unknown tool annotations", "tool", tool, "error", err)
+		return nil, s.confirmCall(tool, params, "may perform destructive updates, its annotations are unknown")
+	}
+	i := slices.IndexFunc(tools, func(t *mcp.Tool) bool { return t.Name == tool })
+	if i < 0 {
+		return nil, s.confirmCall(tool, params, "is not listed by the server")
+	}
+	t := tools[i]
+	if !s.NoValidate {
+		if err := Validate(fmt.Sprintf("arguments of tool %s", tool), t.InputSchema, params); err != nil {
+			return nil, err
+		}
+	}
+	if Destructive(t) {
+		if err := s.confirmCall(tool, params, "may perform destructive updates"); err != nil {
+			return nil, err
+		}
+	}
+	if s.NoValidate {
+		return nil, nil
+	}
+	return t, nil
+}
+
+// confirmCall asks for confirmation before calling the tool, the call is refused without Confirm.
+func (s ServerFeatures) confirmCall(tool string, params map[string]any, reason string) error {
+	if s.Confirm == nil {
+		return fmt.Errorf("call tool %s: %w", tool, ErrNotConfirmed)
+	}
+	arguments, _ := json.Marshal(params)
+	ok, err := s.Confirm(fmt.Sprintf("\033[33mTool %s %s, call it with %s? [y/N]\033[0m ", tool, reason, arguments))
+	if err != nil {
+		return fmt.Errorf("confirm tool call: %w", err)
+	}
+	if !ok {
+		return fmt.Errorf("call tool %s: %w", tool, ErrNotConfirmed)
+	}
+	return nil
+}
+
+// validateStructuredContent validates the structured content of the result against the output schema of the tool.
+func (s ServerFeatures) validateStructuredContent(tool *mcp.Tool, result *mcp.CallToolResult) error {
+	if tool == nil || tool.OutputSchema == nil || result.IsError {
+		return nil
+	}
+	if result.StructuredContent == nil {
+		return fmt.Errorf("tool %s declares an output schema but returned no structured content", tool.Name)
+	}
+	return Validate(fmt.Sprintf("structured content of tool %s", tool.Name), tool.OutputSchema, result.StructuredContent)
+}
+
 func (s ServerFeatures) GetPrompt(ctx context.Context, prompt, data string) error {
 	params := map[string]string{}
 	if data != "" {
@@ -97,20 +173,21 @@ func (s ServerFeatures) ListPrompts(ctx context.Context) ([]*mcp.Prompt, error)
 	if s.Session == nil {
 		return nil, ErrNoSession
 	}
-	params := &mcp.ListPromptsParams{}
-	var prompts []*mcp.Prompt
-	for {
-		result, err := s.Session.ListPrompts(ctx, params)
-		if err != nil {
-			return nil, fmt.Errorf("list prompts: %w", err)
-		}
-		prompts = append(prompts, result.Prompts...)
-		if result.NextCursor == "" {
-			break
+	return s.cache().prompts.get(func() ([]*mcp.Prompt, error) {
+		params := &mcp.ListPromptsParams{}
+		var prompts []*mcp.Prompt
+		for {
+			result, err := s.Session.ListPrompts(ctx, params)
+			if err != nil {
+				return nil, fmt.Errorf("list prompts: %w", err)
+			}
+			prompts = append(prompts, result.Prompts...)
+			if result.NextCursor == "" {
+				return prompts, nil
+			}
+			params.Cursor = result.NextCursor
 		}
-		params.Cursor = result.NextCursor
-	}
-	return prompts, nil
+	})
 }
 
 func (s ServerFeatures) PrintPrompts(ctx context.Context) error {
@@ -128,20 +205,21 @@ func (s ServerFeatures) ListResources(ctx context.Context) ([]*mcp.Resource, err
 	if s.Session == nil {
 		return nil, ErrNoSession
 	}
-	params := &mcp.ListResourcesParams{}
-	var resources []*mcp.Resource
-	for {
-		result, err := s.Session.ListResources(ctx, params)
-		if err != nil {
-			return nil, fmt.Errorf("list resources: %w", err)
-		}
-		resources = append(resources, result.Resources...)
-		if result.NextCursor == "" {
-			break
+	return s.cache().resources.get(func() ([]*mcp.Resource, error) {
+		params := &mcp.ListResourcesParams{}
+		var resources []*mcp.Resource
+		for {
+			result, err := s.Session.ListResources(ctx, params)
+			if err != nil {
+				return nil, fmt.Errorf("list resources: %w", err)
+			}
+			resources = append(resources, result.Resources...)
+			if result.NextCursor == "" {
+				return resources, nil
+			}
+			params.Cursor = result.NextCursor
 		}
-		params.Cursor = result.NextCursor
-	}
-	return resources, nil
+	})
 }
 
 func (s ServerFeatures) PrintResources(ctx context.Context) error {
@@ -155,6 +233,27 @@ func (s ServerFeatures) PrintResources(ctx context.Context) error {
 	return nil
 }
 
+func (s ServerFeatures) ListResourceTemplates(ctx context.Context) ([]*mcp.ResourceTemplate, error) {
+	if s.Session == nil {
+		return nil, ErrNoSession
+	}
+	return s.cache().templates.get(func() ([]*mcp.ResourceTemplate, error) {
+		params := &mcp.ListResourceTemplatesParams{}
+		var templates []*mcp.ResourceTemplate
+		for {
+			result, err := s.Session.ListResourceTemplates(ctx, params)
+			if err != nil {
+				return nil, fmt.Errorf("list resource templates: %w", err)
+			}
+			templates = append(templates, result.ResourceTemplates...)
+			if result.NextCursor == "" {
+				return templates, nil
+			}
+			params.Cursor = result.NextCursor
+		}
+	})
+}
+
 func (s ServerFeatures) ReadResource(ctx context.Context, resource string) error {
 	if s.Session == nil {
 		return ErrNoSession
@@ -175,20 +274,21 @@ func (s ServerFeatures) ListTools(ctx context.Context) ([]*mcp.Tool, error) {
 	if s.Session == nil {
 		return nil, ErrNoSession
 	}
-	params := &mcp.ListToolsParams{}
-	var tools []*mcp.Tool
-	for {
-		result, err := s.Session.ListTools(ctx, params)
-		if err != nil {
-			return nil, fmt.Errorf("list tools: %w", err)
-		}
-		tools = append(tools, result.Tools...)
-		if result.NextCursor == "" {
-			break
+	return s.cache().tools.get(func() ([]*mcp.Tool, error) {
+		params := &mcp.ListToolsParams{}
+		var tools []*mcp.Tool
+		for {
+			result, err := s.Session.ListTools(ctx, params)
+			if err != nil {
+				return nil, fmt.Errorf("list tools: %w", err)
+			}
+			tools = append(tools, result.Tools...)
+			if result.NextCursor == "" {
+				return tools, nil
+			}
+			params.Cursor = result.NextCursor
 		}
-		params.Cursor = result.NextCursor
-	}
-	return tools, nil
+	})
 }
 
 func (s ServerFeatures) PrintTools(ctx context.Context) error {
@@ -0,0 +1,121 @@
+package features
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonschema"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const testSchema = `{
+	"type": "object",
+	"required": ["text"],
+	"properties": {
+		"text": {"type": "string"},
+		"count": {"type": "integer", "minimum": 1},
+		"mode": {"enum": ["a", "b"]},
+		"tags": {"type": "array", "items": {"type": "string"}},
+		"filter": {
+			"type": "object",
+			"properties": {"owner": {"type": "string"}, "limit": {"type": "integer"}},
+			"additionalProperties": false
+		},
+		"labels": {"type": "object", "additionalProperties": {"type": "integer"}},
+		"user": {"$ref": "#/$defs/user"},
+		"users": {"type": "array", "items": {"$ref": "#/$defs/user"}}
+	},
+	"$defs": {
+		"user": {"type": "object", "required": ["name"], "properties": {"name": {"type": "string"}}}
+	}
+}`
+
+func TestValidate(t *testing.T) {
+	var schema jsonschema.Schema
+	if err := json.Unmarshal([]byte(testSchema), &schema); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name  string
+		value string
+		path  string // the json path of the violation, empty if valid
+	}{
+		{"valid", `{"text": "hi"}`, ""},
+		{"all valid", `{"text": "hi", "count": 2, "mode": "a", "tags": ["x"], "filter": {"owner": "me"},
+			"labels": {"a": 1}, "user": {"name": "n"}, "users": [{"name": "n"}]}`, ""},
+		{"extra property", `{"text": "hi", "other": 1}`, ""},
+		{"missing required", `{}`, "$"},
+		{"wrong type", `{"text": 1}`, "$.text"},
+		{"minimum", `{"text": "hi", "count": 0}`, "$.count"},
+		{"enum", `{"text": "hi", "mode": "c"}`, "$.mode"},
+		{"item", `{"text": "hi", "tags": ["x", 2]}`, "$.tags[1]"},
+		{"nested property", `{"text": "hi", "filter": {"limit": "5"}}`, "$.filter.limit"},
+		{"additional property not allowed", `{"text": "hi", "filter": {"other": 1}}`, "$.filter.other"},
+		{"additional properties schema", `{"text": "hi", "labels": {"a": "x"}}`, "$.labels.a"},
+		{"reference", `{"text": "hi", "user": {}}`, "$.user"},
+		{"reference property", `{"text": "hi", "user": {"name": 1}}`, "$.user.name"},
+		{"reference item", `{"text": "hi", "users": [{"name": "n"}, {"name": 1}]}`, "$.users[1].name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var value any
+			if err := json.Unmarshal([]byte(tt.value), &value); err != nil {
+				t.Fatal(err)
+			}
+			err := Validate("arguments", &schema, value)
+			if tt.path == "" {
+				if err != nil {
+					t.Errorf("Validate(%s) error: %v", tt.value, err)
+				}
+				return
+			}
+			var invalid *ValidationError
+			if !errors.As(err, &invalid) {
+				t.Fatalf("Validate(%s) error = %v, want a ValidationError", tt.value, err)
+			}
+			if len(invalid.Errors) != 1 || !strings.HasPrefix(invalid.Errors[0], tt.path+": ") {
+				t.Errorf("Validate(%s) errors = %q, want one at %s", tt.value, invalid.Errors, tt.path)
+			}
+			if strings.Contains(invalid.Errors[0], "validating") {
+				t.Errorf("Validate(%s) error %q keeps the schemas of the sdk", tt.value, invalid.Errors[0])
+			}
+		})
+	}
+}
+
+func TestValidateNilSchema(t *testing.T) {
+	if err := Validate("arguments", nil, map[string]any{"a": 1}); err != nil {
+		t.Errorf("Validate with a nil schema error: %v", err)
+	}
+}
+
+func TestHints(t *testing.T) {
+	yes, no := true, false
+	tests := []struct {
+		name        string
+		annotations *mcp.ToolAnnotations
+		destructive bool
+		hints       string
+	}{
+		{"no annotations", nil, true, "destructive,open-world"},
+		{"empty annotations", &mcp.ToolAnnotations{}, true, "destructive,open-world"},
+		{"read-only", &mcp.ToolAnnotations{ReadOnlyHint: true}, false, "read-only,open-world"},
+		{"read-only and destructive", &mcp.ToolAnnotations{ReadOnlyHint: true, DestructiveHint: &yes}, false, "read-only,open-world"},
+		{"not destructive", &mcp.ToolAnnotations{DestructiveHint: &no}, false, "open-world"},
+		{"destructive", &mcp.ToolAnnotations{DestructiveHint: &yes}, true, "destructive,open-world"},
+		{"closed world", &mcp.ToolAnnotations{DestructiveHint: &no, IdempotentHint: true, OpenWorldHint: &no}, false, "idempotent"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tool := &mcp.Tool{Name: "t", Annotations: tt.annotations}
+			if got := Destructive(tool); got != tt.destructive {
+				t.Errorf("Destructive = %v, want %v", got, tt.destructive)
+			}
+			if got := strings.Join(Hints(tool), ","); got != tt.hints {
+				t.Errorf("Hints = %q, want %q", got, tt.hints)
+			}
+		})
+	}
+}
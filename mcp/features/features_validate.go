@@ -0,0 +1,185 @@
+package features
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonschema"
+)
+
+// ValidationError is a violation found while validating a value against a schema, reported with its json path.
+type ValidationError struct {
+	Subject string
+	Errors  []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid %s:\n  %s", e.Subject, strings.Join(e.Errors, "\n  "))
+}
+
+// Validate checks value against schema with the jsonschema package of the sdk.
+// The violation is reported at the json path of the deepest property or item that fails its own schema.
+func Validate(subject string, schema *jsonschema.Schema, value any) error {
+	if schema == nil {
+		return nil
+	}
+	// normalize go values to their json representation
+	instance, err := toJSON(value)
+	if err != nil {
+		return fmt.Errorf("marshal %s: %w", subject, err)
+	}
+	// the schema is resolved from a copy, resolving annotates the schema and the tool schemas are shared
+	var root map[string]any
+	if b, err := json.Marshal(schema); err != nil {
+		return fmt.Errorf("marshal %s schema: %w", subject, err)
+	} else if err := json.Unmarshal(b, &root); err != nil {
+		return fmt.Errorf("unmarshal %s schema: %w", subject, err)
+	}
+	err = validate(root, root, instance)
+	if err == nil {
+		return nil
+	}
+	var invalid *violation
+	if !errors.As(err, &invalid) {
+		return fmt.Errorf("%s schema: %w", subject, err)
+	}
+	path, err := locate(root, root, "$", instance, invalid.err)
+	return &ValidationError{Subject: subject, Errors: []string{fmt.Sprintf("%s: %v", path, err)}}
+}
+
+// violation is a validation failure, as opposed to a schema that does not resolve.
+type violation struct {
+	err error
+}
+
+func (v *violation) Error() string {
+	return v.err.Error()
+}
+
+// validate validates instance against schema, a sub-schema of root which keeps the definitions of root.
+func validate(root map[string]any, schema any, instance any) error {
+	if m, ok := schema.(map[string]any); ok {
+		schema = withDefinitions(root, m)
+	}
+	var s jsonschema.Schema
+	if b, err := json.Marshal(schema); err != nil {
+		return err
+	} else if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	resolved, err := s.Resolve(nil)
+	if err != nil {
+		return err
+	}
+	if err := resolved.Validate(instance); err != nil {
+		return &violation{err}
+	}
+	return nil
+}
+
+// locate descends into the properties and items of instance that fail their own schema and returns the
+// json path of the deepest one with the message of the sdk, stripped of the schemas it went through.
+func locate(root map[string]any, schema any, path string, instance any, err error) (string, error) {
+	m, ok := deref(root, schema).(map[string]any)
+	if !ok {
+		return path, innermost(err)
+	}
+	switch instance := instance.(type) {
+	case map[string]any:
+		properties, _ := m["properties"].(map[string]any)
+		for _, name := range slices.Sorted(maps.Keys(instance)) {
+			sub, ok := properties[name]
+			if !ok {
+				sub, ok = m["additionalProperties"]
+			}
+			if !ok {
+				continue
+			}
+			if sub == false {
+				// the sdk reports the false schema as a failed "not"
+				return path + "." + name, errors.New("property is not allowed")
+			}
+			var invalid *violation
+			if err := validate(root, deref(root, sub), instance[name]); errors.As(err, &invalid) {
+				return locate(root, sub, path+"."+name, instance[name], invalid.err)
+			}
+		}
+	case []any:
+		if items, ok := m["items"]; ok {
+			for i, item := range instance {
+				var invalid *violation
+				if err := validate(root, deref(root, items), item); errors.As(err, &invalid) {
+					return locate(root, items, fmt.Sprintf("%s[%d]", path, i), item, invalid.err)
+				}
+			}
+		}
+	}
+	return path, innermost(err)
+}
+
+// deref replaces a reference to the definitions of root by the referenced schema.
+func deref(root map[string]any, schema any) any {
+	for range 32 {
+		m, ok := schema.(map[string]any)
+		if !ok || len(m) != 1 {
+			return schema
+		}
+		ref, ok := m["$ref"].(string)
+		if !ok {
+			return schema
+		}
+		var defs string
+		if name, ok := strings.CutPrefix(ref, "#/$defs/"); ok {
+			ref, defs = name, "$defs"
+		} else if name, ok := strings.CutPrefix(ref, "#/definitions/"); ok {
+			ref, defs = name, "definitions"
+		} else {
+			return schema
+		}
+		d, _ := root[defs].(map[string]any)
+		sub, ok := d[ref]
+		if !ok {
+			return schema
+		}
+		schema = sub
+	}
+	return schema
+}
+
+// withDefinitions returns schema with the definitions of root so that its references resolve on their own.
+func withDefinitions(root, schema map[string]any) map[string]any {
+	ret := maps.Clone(schema)
+	for _, defs := range []string{"$defs", "definitions"} {
+		if d, ok := root[defs]; ok {
+			ret[defs] = d
+		}
+	}
+	return ret
+}
+
+// innermost unwraps the "validating <schema>: " prefixes added by the sdk at every sub-schema.
+func innermost(err error) error {
+	for {
+		next := errors.Unwrap(err)
+		if next == nil {
+			return err
+		}
+		err = next
+	}
+}
+
+func toJSON(value any) (any, error) {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var instance any
+	if err := json.Unmarshal(b, &instance); err != nil {
+		return nil, err
+	}
+	return instance, nil
+}
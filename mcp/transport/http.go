@@ -1,14 +1,53 @@
 package transport
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"os"
 	"strings"
 	"sync"
+
+	"github.com/cherrydra/mcpurl/config"
 )
 
+// HTTPClient returns a http client adding the headers to every request, using the tls config if given.
+func HTTPClient(headers []string, tlsConfig *config.TLS) (*http.Client, error) {
+	rt := &AddHeadersRoundTripper{Headers: headers}
+	if tlsConfig == nil {
+		return &http.Client{Transport: rt}, nil
+	}
+	c := &tls.Config{InsecureSkipVerify: tlsConfig.InsecureSkipVerify}
+	if tlsConfig.CAFile != "" {
+		ca, err := os.ReadFile(tlsConfig.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read ca file: %w", err)
+		}
+		c.RootCAs = x509.NewCertPool()
+		if !c.RootCAs.AppendCertsFromPEM(ca) {
+			return nil, errors.New("no certificates found in ca file")
+		}
+	}
+	if tlsConfig.CertFile != "" || tlsConfig.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(tlsConfig.CertFile, tlsConfig.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate: %w", err)
+		}
+		c.Certificates = []tls.Certificate{cert}
+	}
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	base.TLSClientConfig = c
+	rt.Base = base
+	return &http.Client{Transport: rt}, nil
+}
+
 type AddHeadersRoundTripper struct {
 	Headers []string
+	// Base is the underlying round tripper, defaults to http.DefaultTransport.
+	Base http.RoundTripper
 
 	parsedHeaders    http.Header
 	parseHeadersOnce sync.Once
@@ -19,11 +58,11 @@ func (r *AddHeadersRoundTripper) RoundTrip(req *http.Request) (*http.Response, e
 		slog.Debug("Parsing headers", "headers", strings.Join(r.Headers, ", "))
 		r.parsedHeaders = make(http.Header)
 		for _, header := range r.Headers {
-			kv := strings.Split(header, ":")
-			if len(kv) != 2 {
+			k, v, ok := strings.Cut(header, ":")
+			if !ok {
 				continue
 			}
-			r.parsedHeaders.Add(strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1]))
+			r.parsedHeaders.Add(strings.TrimSpace(k), strings.TrimSpace(v))
 		}
 	})
 	for k, v := range r.parsedHeaders {
@@ -34,5 +73,8 @@ func (r *AddHeadersRoundTripper) RoundTrip(req *http.Request) (*http.Response, e
 			req.Header.Add(k, hv)
 		}
 	}
+	if r.Base != nil {
+		return r.Base.RoundTrip(req)
+	}
 	return http.DefaultTransport.RoundTrip(req)
 }
@@ -4,7 +4,6 @@ import (
 	"cmp"
 	"errors"
 	"fmt"
-	"net/http"
 	"net/url"
 	"os"
 	"os/exec"
@@ -22,41 +21,67 @@ func Transport(args parser.Arguments) (mcp.Transport, error) {
 	if len(args.TransportArgs) == 0 {
 		return nil, ErrNoTransport
 	}
+	switch args.TransportType {
+	case "stdio":
+		return commandTransport(args, args.TransportArgs[0]), nil
+	case "http":
+		return streamableTransport(args, args.TransportArgs[0])
+	case "sse":
+		return sseTransport(args, args.TransportArgs[0])
+	case "":
+	default:
+		return nil, fmt.Errorf("unsupported transport type: %s", args.TransportType)
+	}
 	transportURL, err := url.Parse(args.TransportArgs[0])
 	if err != nil {
 		return nil, fmt.Errorf("parse transport url: %w", err)
 	}
 	switch transportURL.Scheme {
 	case "stdio":
-		cmd := cmp.Or(transportURL.Host, transportURL.Path)
-		command := exec.Command(cmd, args.TransportArgs[1:]...)
-		if !args.Silent {
-			command.Stderr = os.Stderr
-		}
-		return mcp.NewCommandTransport(command), nil
+		return commandTransport(args, cmp.Or(transportURL.Host, transportURL.Path)), nil
 	case "http", "https":
-		return mcp.NewStreamableClientTransport(transportURL.String(), &mcp.StreamableClientTransportOptions{
-			HTTPClient: &http.Client{Transport: &AddHeadersRoundTripper{Headers: args.Headers}},
-		}), nil
+		return streamableTransport(args, transportURL.String())
 	case "":
 		switch filepath.Base(transportURL.Path) {
 		case "mcp":
-			return mcp.NewStreamableClientTransport(fmt.Sprintf("https://%s", transportURL.String()), &mcp.StreamableClientTransportOptions{
-				HTTPClient: &http.Client{Transport: &AddHeadersRoundTripper{Headers: args.Headers}},
-			}), nil
+			return streamableTransport(args, fmt.Sprintf("https://%s", transportURL.String()))
 		case "sse":
-			return mcp.NewSSEClientTransport(fmt.Sprintf("https://%s", transportURL.String()), &mcp.SSEClientTransportOptions{
-				HTTPClient: &http.Client{Transport: &AddHeadersRoundTripper{Headers: args.Headers}},
-			}), nil
+			return sseTransport(args, fmt.Sprintf("https://%s", transportURL.String()))
 		default:
-			cmd := cmp.Or(transportURL.Host, transportURL.Path)
-			command := exec.Command(cmd, args.TransportArgs[1:]...)
-			if !args.Silent {
-				command.Stderr = os.Stderr
-			}
-			return mcp.NewCommandTransport(command), nil
+			return commandTransport(args, cmp.Or(transportURL.Host, transportURL.Path)), nil
 		}
 	default:
 		return nil, fmt.Errorf("unsupportd transport url scheme: %s", transportURL.Scheme)
 	}
 }
+
+func commandTransport(args parser.Arguments, cmd string) mcp.Transport {
+	command := exec.Command(cmd, args.TransportArgs[1:]...)
+	if len(args.Env) > 0 {
+		command.Env = append(os.Environ(), args.Env...)
+	}
+	if !args.Silent {
+		command.Stderr = os.Stderr
+	}
+	return mcp.NewCommandTransport(command)
+}
+
+func streamableTransport(args parser.Arguments, endpoint string) (mcp.Transport, error) {
+	httpClient, err := HTTPClient(args.Headers, args.TLS)
+	if err != nil {
+		return nil, fmt.Errorf("http client: %w", err)
+	}
+	return mcp.NewStreamableClientTransport(endpoint, &mcp.StreamableClientTransportOptions{
+		HTTPClient: httpClient,
+	}), nil
+}
+
+func sseTransport(args parser.Arguments, endpoint string) (mcp.Transport, error) {
+	httpClient, err := HTTPClient(args.Headers, args.TLS)
+	if err != nil {
+		return nil, fmt.Errorf("http client: %w", err)
+	}
+	return mcp.NewSSEClientTransport(endpoint, &mcp.SSEClientTransportOptions{
+		HTTPClient: httpClient,
+	}), nil
+}
@@ -0,0 +1,70 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Flag describes a command line option, it is used for generating the usage and the shell completions.
+type Flag struct {
+	Short string
+	Long  string
+	Arg   string // name of the value, empty for switches
+	Usage string
+}
+
+// Flags are the options accepted by the parser, the ones with an Arg take a value.
+var Flags = []Flag{
+	{"-T", "--tools", "", "List tools"},
+	{"-P", "--prompts", "", "List prompts"},
+	{"-R", "--resources", "", "List resources"},
+	{"-t", "--tool", "string", "Call tool"},
+	{"-p", "--prompt", "string", "Get prompt"},
+	{"-r", "--resource", "string", "Read resource"},
+	{"-d", "--data", "string/@file", "Send json data to server, @- reads stdin"},
+	{"-H", "--header", "header/@file", "Pass custom header(s) to server"},
+	{"-f", "--file", "script", "Run interactor commands from script file"},
+	{"-h", "--help", "", "Show this usage"},
+	{"", "--info", "", "Show server info and capabilities"},
+	{"-I", "--interactive", "", "Start interactive mode"},
+	{"-K", "--llm-api-key", "key", "API key for authenticating with the LLM"},
+	{"-L", "--llm-base-url", "url", "Base URL of the LLM service"},
+	{"", "--listen", "addr", "Listen address of the inspector (default 127.0.0.1:6274)"},
+	{"", "--llm", "profile", "Use the LLM profile from the config file"},
+	{"-M", "--llm-name", "name", "Name of the LLM model to use"},
+	{"-l", "--log-level", "level", "Set log level (debug, info, warn, error)"},
+	{"-m", "--msg", "message", "Talk to LLM"},
+	{"", "--no-validate", "", "Skip validating tool arguments and results"},
+	{"", "--query", "path", "Filter the json output by gjson path"},
+	{"-s", "--silent", "", "Silent mode"},
+	{"", "--time", "", "Report timing and traffic to stderr"},
+	{"", "--tui", "", "Start full-screen explorer"},
+	{"-v", "--version", "", "Show version"},
+	{"", "--yes", "", "Call destructive tools without confirmation"},
+}
+
+// LookupFlag returns the flag matching the short or long option name.
+func LookupFlag(name string) (Flag, bool) {
+	for _, f := range Flags {
+		if name != "" && (f.Short == name || f.Long == name) {
+			return f, true
+		}
+	}
+	return Flag{}, false
+}
+
+// FlagUsage formats the flags as the options section of the usage, one flag per line.
+func FlagUsage() string {
+	var b strings.Builder
+	for _, f := range Flags {
+		name := "    " + f.Long
+		if f.Short != "" {
+			name = f.Short + ", " + f.Long
+		}
+		if f.Arg != "" {
+			name += " <" + f.Arg + ">"
+		}
+		fmt.Fprintf(&b, "  %-27s %s\n", name, f.Usage)
+	}
+	return b.String()
+}
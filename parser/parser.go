@@ -2,53 +2,110 @@ package parser
 
 import (
 	"bufio"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/cherrydra/mcpurl/config"
 )
 
 var (
 	ErrInvalidUsage = errors.New("invalid usage")
+	// ErrStdinReused is returned when more than one argument of a command reads stdin.
+	ErrStdinReused = errors.New("stdin is read by more than one argument")
 )
 
 type Arguments struct {
 	// Data
 	Data          string
+	Env           []string
 	Headers       []string
+	LLMProfile    string
+	Profile       string
+	Query         string
+	RequestItems  []string
+	TLS           *config.TLS
+	TransportType string
 	LogLevel      slog.Level
 	LLMBaseURL    string
 	LLMApiKey     string
 	LLMName       string
+	NoValidate    bool
 	Silent        bool
+	// Time reports the timing and traffic of the run to stderr.
+	Time          bool
 	TransportArgs []string
+	Yes           bool
 
 	// Actions
-	Help        bool
+	Help bool
+	Info bool
+	// Inspect serves the web inspector on Listen.
+	Inspect     bool
+	Listen      string
 	Interactive bool
 	Msg         string
 	Prompt      string
 	Prompts     bool
 	Resource    string
 	Resources   bool
+	ScriptFile  string
 	Tool        string
 	Tools       bool
+	TUI         bool
 	Version     bool
 
+	// Completion is the shell to print the completion script for.
+	Completion string
+	// CompleteWords are the words to complete, the last one is the word under the cursor.
+	CompleteWords []string
+	Complete      bool
+
+	ConfigFile     string
 	HistoryFile    string
 	LLMContextFile string
+	// RCFile is the script run when the interactor starts.
+	RCFile string
+	// ArgumentsFile keeps the last arguments edited for each tool of each server.
+	ArgumentsFile string
 }
 
 type Parser struct {
 	args Arguments
+	// Stdin is read by the @- data and the - values of the request items, os.Stdin if nil.
+	// It is read once, by a single argument.
+	Stdin     io.Reader
+	stdinRead bool
 }
 
 func (p *Parser) Parse(args []string) error {
 	if err := p.applyFromEnv(); err != nil {
 		return fmt.Errorf("apply from env: %w", err)
 	}
+	fromEnv := p.args
+
+	if len(args) > 0 {
+		switch args[0] {
+		case "completion":
+			if len(args) != 2 {
+				return ErrInvalidUsage
+			}
+			p.args.Completion = args[1]
+			return nil
+		case "__complete":
+			p.args.Complete = true
+			p.args.CompleteWords = args[1:]
+			return nil
+		case "inspect":
+			p.args.Inspect = true
+			args = args[1:]
+		}
+	}
 
 	for i := 0; i < len(args); i++ {
 		arg := args[i]
@@ -62,26 +119,37 @@ func (p *Parser) Parse(args []string) error {
 		case "-h", "--help":
 			p.args.Help = true
 			return nil
+		case "--info":
+			p.args.Info = true
 		case "-I", "--interactive":
 			p.args.Silent = true
 			p.args.Interactive = true
+		case "--tui":
+			p.args.Silent = true
+			p.args.TUI = true
 		case "-s", "--silent":
 			p.args.Silent = true
+		case "--no-validate":
+			p.args.NoValidate = true
+		case "--yes":
+			p.args.Yes = true
+		case "--time":
+			p.args.Time = true
 		case "-v", "--version":
 			p.args.Version = true
 			return nil
 		default:
-			switch arg {
-			case "-t", "--tool", "-p", "--prompt", "-r", "--resource", "-d", "--data", "-H", "--header", "-l", "--log-level",
-				"-K", "--llm-api-key", "-L", "--llm-base-url", "-M", "--llm-name", "-m", "--msg":
+			if f, ok := LookupFlag(arg); ok && f.Arg != "" {
 				if len(args) < i+2 {
 					return ErrInvalidUsage
 				}
 				switch arg {
 				case "-t", "--tool":
 					p.args.Tool = args[i+1]
+					i += p.consumeRequestItems(args[i+2:])
 				case "-p", "--prompt":
 					p.args.Prompt = args[i+1]
+					i += p.consumeRequestItems(args[i+2:])
 				case "-r", "--resource":
 					p.args.Resource = args[i+1]
 				case "-d", "--data":
@@ -106,16 +174,40 @@ func (p *Parser) Parse(args []string) error {
 					p.args.LLMBaseURL = args[i+1]
 				case "-M", "--llm-name":
 					p.args.LLMName = args[i+1]
+				case "--llm":
+					p.args.LLMProfile = args[i+1]
+				case "-f", "--file":
+					p.args.ScriptFile = args[i+1]
+				case "--query":
+					p.args.Query = args[i+1]
+				case "--listen":
+					p.args.Listen = args[i+1]
 				case "-m", "--msg":
 					p.args.Msg = args[i+1]
 				}
 				i++
-			default:
+			} else {
+				if name, ok := strings.CutPrefix(arg, "@"); ok && len(p.args.TransportArgs) == 0 && p.args.Profile == "" {
+					p.args.Profile = name
+					continue
+				}
 				p.args.TransportArgs = append(p.args.TransportArgs, arg)
 			}
 		}
 	}
 
+	if err := p.applyProfiles(fromEnv); err != nil {
+		return fmt.Errorf("apply profiles: %w", err)
+	}
+
+	if len(p.args.RequestItems) > 0 {
+		data, err := p.ParseRequestItems(p.args.Data, p.args.RequestItems)
+		if err != nil {
+			return fmt.Errorf("parse request items: %w", err)
+		}
+		p.args.Data = data
+	}
+
 	if err := p.checkArgs(); err != nil {
 		return fmt.Errorf("check args: %w", err)
 	}
@@ -126,11 +218,24 @@ func (p *Parser) Arguments() Arguments {
 	return p.args
 }
 
-func (p Parser) ParseData(arg string) (string, error) {
+// Server returns the display name of the mcp server, the profile name if connected by profile.
+func (a Arguments) Server() string {
+	if a.Profile != "" {
+		return "@" + a.Profile
+	}
+	return strings.Join(a.TransportArgs, " ")
+}
+
+// ParseData returns the json data of the argument, @file reads the file and @- reads stdin.
+func (p *Parser) ParseData(arg string) (string, error) {
 	after, ok := strings.CutPrefix(arg, "@")
 	if !ok {
 		return after, nil
 	}
+	if after == "-" {
+		d, err := p.ReadStdin()
+		return strings.TrimSpace(d), err
+	}
 	d, err := os.ReadFile(after)
 	if err != nil {
 		return "", fmt.Errorf("read data file: %w", err)
@@ -138,6 +243,95 @@ func (p Parser) ParseData(arg string) (string, error) {
 	return strings.TrimSpace(string(d)), nil
 }
 
+// IsRequestItem reports whether arg is a request item: key=value, key:=json, key=@file or key:=@file.json.
+func (p Parser) IsRequestItem(arg string) bool {
+	key, _, ok := strings.Cut(arg, "=")
+	key = strings.TrimSuffix(key, ":")
+	if !ok || key == "" {
+		return false
+	}
+	for i, r := range key {
+		switch {
+		case r == '_', r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		case i > 0 && (r == '.' || r == '-' || r >= '0' && r <= '9'):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// ReadStdin reads the whole stdin, for the argument given as - or @-.
+// Reading it for a second argument fails with ErrStdinReused.
+func (p *Parser) ReadStdin() (string, error) {
+	if p.stdinRead {
+		return "", ErrStdinReused
+	}
+	p.stdinRead = true
+	in := p.Stdin
+	if in == nil {
+		in = os.Stdin
+	}
+	b, err := io.ReadAll(in)
+	if err != nil {
+		return "", fmt.Errorf("read stdin: %w", err)
+	}
+	return string(b), nil
+}
+
+// ParseRequestItems merges the request items into the json object data.
+// Dotted keys address nested objects, e.g. filter.owner=me, and key=- reads the value from stdin.
+func (p *Parser) ParseRequestItems(data string, items []string) (string, error) {
+	params := map[string]any{}
+	if data != "" {
+		if err := json.Unmarshal([]byte(data), &params); err != nil {
+			return "", fmt.Errorf("unmarshal data: %w", err)
+		}
+	}
+	for _, item := range items {
+		key, value, _ := strings.Cut(item, "=")
+		key, raw := strings.CutSuffix(key, ":")
+		if file, ok := strings.CutPrefix(value, "@"); ok || value == "-" {
+			var err error
+			if value == "-" || file == "-" {
+				value, err = p.ReadStdin()
+			} else {
+				var b []byte
+				b, err = os.ReadFile(file)
+				value = string(b)
+			}
+			if err != nil {
+				return "", fmt.Errorf("read %s: %w", key, err)
+			}
+			if raw {
+				value = strings.TrimSpace(value)
+			}
+		}
+		var v any = value
+		if raw {
+			if err := json.Unmarshal([]byte(value), &v); err != nil {
+				return "", fmt.Errorf("parse %s: %w", key, err)
+			}
+		}
+		obj := params
+		keys := strings.Split(key, ".")
+		for _, k := range keys[:len(keys)-1] {
+			nested, ok := obj[k].(map[string]any)
+			if !ok {
+				nested = map[string]any{}
+				obj[k] = nested
+			}
+			obj = nested
+		}
+		obj[keys[len(keys)-1]] = v
+	}
+	b, err := json.Marshal(params)
+	if err != nil {
+		return "", fmt.Errorf("marshal data: %w", err)
+	}
+	return string(b), nil
+}
+
 func (p Parser) ParseHeader(header string) ([]string, error) {
 	var ret []string
 	after, ok := strings.CutPrefix(header, "@")
@@ -159,6 +353,75 @@ func (p Parser) ParseHeader(header string) ([]string, error) {
 	return ret, nil
 }
 
+// consumeRequestItems collects the request items leading args, returns the number consumed.
+func (p *Parser) consumeRequestItems(args []string) int {
+	var n int
+	for _, arg := range args {
+		if !p.IsRequestItem(arg) {
+			break
+		}
+		p.args.RequestItems = append(p.args.RequestItems, arg)
+		n++
+	}
+	return n
+}
+
+// applyProfiles applies the server and llm profiles from the config file,
+// llm options given on the command line take precedence over the llm profile.
+func (p *Parser) applyProfiles(fromEnv Arguments) error {
+	if p.args.Profile == "" && p.args.LLMProfile == "" {
+		return nil
+	}
+	conf, err := config.Profiles(p.args.ConfigFile)
+	if err != nil {
+		return err
+	}
+	if p.args.Profile != "" {
+		server, ok := conf.Servers[p.args.Profile]
+		if !ok {
+			return fmt.Errorf("server profile not found: %s", p.args.Profile)
+		}
+		var transportArgs []string
+		switch server.Type {
+		case "stdio", "":
+			p.args.TransportType = "stdio"
+			transportArgs = append(transportArgs, os.ExpandEnv(server.Command))
+			for _, arg := range server.Args {
+				transportArgs = append(transportArgs, os.ExpandEnv(arg))
+			}
+		case "http", "sse":
+			p.args.TransportType = server.Type
+			transportArgs = append(transportArgs, os.ExpandEnv(server.URL))
+		default:
+			return fmt.Errorf("unsupported server type: %s", server.Type)
+		}
+		p.args.TransportArgs = append(transportArgs, p.args.TransportArgs...)
+		for _, env := range server.Env.Encode() {
+			p.args.Env = append(p.args.Env, os.ExpandEnv(env))
+		}
+		for _, header := range server.Headers.Headers() {
+			p.args.Headers = append(p.args.Headers, os.ExpandEnv(header))
+		}
+		p.args.TLS = server.TLS
+	}
+	if p.args.LLMProfile != "" {
+		profile, ok := conf.LLMs[p.args.LLMProfile]
+		if !ok {
+			return fmt.Errorf("llm profile not found: %s", p.args.LLMProfile)
+		}
+		if p.args.LLMBaseURL == fromEnv.LLMBaseURL {
+			p.args.LLMBaseURL = os.ExpandEnv(profile.BaseURL)
+		}
+		if p.args.LLMApiKey == fromEnv.LLMApiKey {
+			p.args.LLMApiKey = os.ExpandEnv(profile.APIKey)
+		}
+		if p.args.LLMName == fromEnv.LLMName {
+			p.args.LLMName = os.ExpandEnv(profile.Model)
+		}
+	}
+	return nil
+}
+
 func (p *Parser) applyFromEnv() error {
 	if v := os.Getenv("MCPURL_LLM_API_KEY"); v != "" {
 		p.args.LLMApiKey = v
@@ -174,6 +437,11 @@ func (p *Parser) applyFromEnv() error {
 			return fmt.Errorf("parse log level: %w", err)
 		}
 	}
+	if v := os.Getenv("MCPURL_CONFIG_FILE"); v != "" {
+		p.args.ConfigFile = v
+	} else {
+		p.args.ConfigFile = configFile()
+	}
 	if v := os.Getenv("MCPURL_HISTORY_FILE"); v != "" {
 		p.args.HistoryFile = v
 	} else {
@@ -184,6 +452,16 @@ func (p *Parser) applyFromEnv() error {
 	} else {
 		p.args.LLMContextFile = llmContextFile()
 	}
+	if v := os.Getenv("MCPURL_RC"); v != "" {
+		p.args.RCFile = v
+	} else {
+		p.args.RCFile = rcFile()
+	}
+	if v := os.Getenv("MCPURL_ARGUMENTS_FILE"); v != "" {
+		p.args.ArgumentsFile = v
+	} else {
+		p.args.ArgumentsFile = argumentsFile()
+	}
 	return nil
 }
 
@@ -194,6 +472,14 @@ func (p Parser) checkArgs() error {
 	return nil
 }
 
+func configFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "mcpurl", "config.json")
+}
+
 func historyFile() string {
 	home, err := os.UserHomeDir()
 	if err != nil {
@@ -209,3 +495,19 @@ func llmContextFile() string {
 	}
 	return filepath.Join(home, ".mcpurl_llm_contexts")
 }
+
+func rcFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".mcpurlrc")
+}
+
+func argumentsFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".mcpurl_arguments")
+}
@@ -0,0 +1,121 @@
+package parser
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIsRequestItem(t *testing.T) {
+	tests := []struct {
+		arg  string
+		want bool
+	}{
+		{"text=hi", true},
+		{"n:=1", true},
+		{"filter.owner=me", true},
+		{"a-b_2=x", true},
+		{"text=", true},
+		{"text", false},
+		{"=x", false},
+		{":=1", false},
+		{"2a=x", false},
+		{".a=x", false},
+		{"a b=x", false},
+		{"--data=x", false},
+		{"https://example.com/?a=b", false},
+	}
+	var p Parser
+	for _, tt := range tests {
+		t.Run(tt.arg, func(t *testing.T) {
+			if got := p.IsRequestItem(tt.arg); got != tt.want {
+				t.Errorf("IsRequestItem(%q) = %v, want %v", tt.arg, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRequestItems(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "value.json")
+	if err := os.WriteFile(file, []byte(" [1, 2]\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name  string
+		data  string
+		items []string
+		stdin string
+		want  string
+	}{
+		{"empty", "", nil, "", `{}`},
+		{"data", `{"a":1}`, nil, "", `{"a":1}`},
+		{"string", "", []string{"text=hi"}, "", `{"text":"hi"}`},
+		{"equals in value", "", []string{"q=a=b"}, "", `{"q":"a=b"}`},
+		{"json", "", []string{"n:=1", "ok:=true", "tags:=[\"x\"]"}, "", `{"n":1,"ok":true,"tags":["x"]}`},
+		{"json number as string", "", []string{"n=1"}, "", `{"n":"1"}`},
+		{"nested", "", []string{"filter.owner=me", "filter.limit:=5"}, "", `{"filter":{"limit":5,"owner":"me"}}`},
+		{"nested over data", `{"filter":{"owner":"you"},"a":1}`, []string{"filter.limit:=5"}, "", `{"a":1,"filter":{"limit":5,"owner":"you"}}`},
+		{"nested replaces a value", `{"filter":1}`, []string{"filter.owner=me"}, "", `{"filter":{"owner":"me"}}`},
+		{"override data", `{"a":1}`, []string{"a=x"}, "", `{"a":"x"}`},
+		{"file", "", []string{"text=@" + file}, "", `{"text":" [1, 2]\n"}`},
+		{"json file", "", []string{"ids:=@" + file}, "", `{"ids":[1,2]}`},
+		{"stdin", "", []string{"text=-"}, "line\n", `{"text":"line\n"}`},
+		{"stdin file", "", []string{"text=@-"}, "line\n", `{"text":"line\n"}`},
+		{"json stdin", "", []string{"n:=-"}, " 3\n", `{"n":3}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := Parser{Stdin: strings.NewReader(tt.stdin)}
+			got, err := p.ParseRequestItems(tt.data, tt.items)
+			if err != nil {
+				t.Fatalf("ParseRequestItems(%q, %q) error: %v", tt.data, tt.items, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseRequestItems(%q, %q)\n got: %s\nwant: %s", tt.data, tt.items, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRequestItemsError(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  string
+		items []string
+		err   error
+	}{
+		{"invalid data", `{"a":`, nil, nil},
+		{"invalid json", "", []string{"n:=x"}, nil},
+		{"missing file", "", []string{"text=@" + filepath.Join(t.TempDir(), "missing")}, os.ErrNotExist},
+		{"stdin twice", "", []string{"a=-", "b=@-"}, ErrStdinReused},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := Parser{Stdin: strings.NewReader("x")}
+			_, err := p.ParseRequestItems(tt.data, tt.items)
+			if err == nil {
+				t.Fatalf("ParseRequestItems(%q, %q) succeeded, want an error", tt.data, tt.items)
+			}
+			if tt.err != nil && !errors.Is(err, tt.err) {
+				t.Errorf("ParseRequestItems(%q, %q) error = %v, want %v", tt.data, tt.items, err, tt.err)
+			}
+		})
+	}
+}
+
+func TestParseDataStdinReused(t *testing.T) {
+	p := Parser{Stdin: strings.NewReader(" {\"a\":1}\n")}
+	data, err := p.ParseData("@-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data != `{"a":1}` {
+		t.Errorf("ParseData(@-) = %q, want %q", data, `{"a":1}`)
+	}
+	if _, err := p.ParseRequestItems(data, []string{"b=-"}); !errors.Is(err, ErrStdinReused) {
+		t.Errorf("ParseRequestItems after ParseData(@-) error = %v, want %v", err, ErrStdinReused)
+	}
+}
@@ -0,0 +1,226 @@
+// Package stats measures the timing and traffic of the commands run under the time builtin or with --time.
+// The stats are collected from the context of the command by the mcp sessions and the LLM.
+package stats
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Stats is the timing and traffic of a command.
+type Stats struct {
+	start time.Time
+
+	mu         sync.Mutex
+	sent       int64
+	received   int64
+	connect    time.Duration
+	initialize time.Duration
+	requests   []Request
+	firstToken time.Duration
+	llm        time.Duration
+	tools      time.Duration
+}
+
+// Request is the round trip of a request sent to a server.
+type Request struct {
+	Method   string   `json:"method"`
+	Duration Duration `json:"duration"`
+	Error    string   `json:"error,omitzero"`
+}
+
+// Duration is a time.Duration encoded as a string such as "1.5ms".
+type Duration time.Duration
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).Round(time.Microsecond).String()), nil
+}
+
+// Start starts measuring a command.
+func Start() *Stats {
+	return &Stats{start: time.Now()}
+}
+
+type statsKey struct{}
+
+// NewContext returns the context of the command measured by s.
+func NewContext(ctx context.Context, s *Stats) context.Context {
+	return context.WithValue(ctx, statsKey{}, s)
+}
+
+// FromContext returns the stats of the command, nil if it is not measured.
+func FromContext(ctx context.Context) *Stats {
+	s, _ := ctx.Value(statsKey{}).(*Stats)
+	return s
+}
+
+// Request records the round trip of a request, the initialize request is the initialize time.
+func (s *Stats) Request(method string, d time.Duration, err error) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if method == "initialize" {
+		s.initialize += d
+	}
+	r := Request{Method: method, Duration: Duration(d)}
+	if err != nil {
+		r.Error = err.Error()
+	}
+	s.requests = append(s.requests, r)
+}
+
+// LLM records a completion of the LLM, firstToken is the time until its first chunk.
+// The time to first token is the one of the first completion.
+func (s *Stats) LLM(firstToken, d time.Duration) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.llm == 0 {
+		s.firstToken = firstToken
+	}
+	s.llm += d
+}
+
+// Tool records a tool called for the LLM.
+func (s *Stats) Tool(d time.Duration) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tools += d
+}
+
+// traffic records the bytes of a message sent or received for the command.
+func (s *Stats) traffic(sent, received int64) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sent += sent
+	s.received += received
+}
+
+func (s *Stats) connected(d time.Duration) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.connect += d
+}
+
+// Report prints the stats as a json line, the bytes are the ones of the json-rpc messages of the command.
+func (s *Stats) Report(w io.Writer) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return json.NewEncoder(w).Encode(struct {
+		Real       Duration  `json:"real"`
+		Connect    Duration  `json:"connect,omitzero"`
+		Initialize Duration  `json:"initialize,omitzero"`
+		Requests   []Request `json:"requests,omitzero"`
+		Sent       int64     `json:"bytes_sent"`
+		Received   int64     `json:"bytes_received"`
+		FirstToken Duration  `json:"llm_first_token,omitzero"`
+		LLM        Duration  `json:"llm,omitzero"`
+		Tools      Duration  `json:"tools,omitzero"`
+	}{
+		Real:       Duration(time.Since(s.start)),
+		Connect:    Duration(s.connect),
+		Initialize: Duration(s.initialize),
+		Requests:   s.requests,
+		Sent:       s.sent,
+		Received:   s.received,
+		FirstToken: Duration(s.firstToken),
+		LLM:        Duration(s.llm),
+		Tools:      Duration(s.tools),
+	})
+}
+
+// Transport measures the connect time of the transport. The connection is returned as is,
+// the sdk relies on the type of its connections.
+func Transport(t mcp.Transport) mcp.Transport {
+	return &transport{t}
+}
+
+type transport struct {
+	mcp.Transport
+}
+
+func (t *transport) Connect(ctx context.Context) (mcp.Connection, error) {
+	start := time.Now()
+	conn, err := t.Transport.Connect(ctx)
+	FromContext(ctx).connected(time.Since(start))
+	return conn, err
+}
+
+// Middleware records the round trips of the requests sent by a client and the bytes of their messages.
+func Middleware(next mcp.MethodHandler[*mcp.ClientSession]) mcp.MethodHandler[*mcp.ClientSession] {
+	return func(ctx context.Context, cs *mcp.ClientSession, method string, params mcp.Params) (mcp.Result, error) {
+		s := FromContext(ctx)
+		if s == nil {
+			return next(ctx, cs, method, params)
+		}
+		start := time.Now()
+		result, err := next(ctx, cs, method, params)
+		if strings.HasPrefix(method, "notifications/") {
+			s.traffic(requestSize(method, params, false), 0)
+			return result, err
+		}
+		s.Request(method, time.Since(start), err)
+		received := int64(0)
+		if ctx.Err() == nil {
+			// a cancelled request has no response
+			received = responseSize(result, err)
+		}
+		s.traffic(requestSize(method, params, true), received)
+		return result, err
+	}
+}
+
+// message is a json-rpc message, the id of the requests is counted as a single digit.
+type message struct {
+	Version string `json:"jsonrpc"`
+	ID      any    `json:"id,omitempty"`
+	Method  string `json:"method,omitempty"`
+	Params  any    `json:"params,omitempty"`
+	Result  any    `json:"result,omitempty"`
+	Error   any    `json:"error,omitempty"`
+}
+
+// requestSize returns the length of the request encoded as json-rpc, a notification has no id.
+func requestSize(method string, params mcp.Params, call bool) int64 {
+	m := message{Version: "2.0", Method: method, Params: params}
+	if call {
+		m.ID = 1
+	}
+	return encodedSize(m)
+}
+
+// responseSize returns the length of the response encoded as json-rpc, an error is counted by its message.
+func responseSize(result mcp.Result, err error) int64 {
+	m := message{Version: "2.0", ID: 1, Result: result}
+	if err != nil {
+		m.Result, m.Error = nil, map[string]any{"code": 0, "message": err.Error()}
+	}
+	return encodedSize(m)
+}
+
+func encodedSize(m message) int64 {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return 0
+	}
+	return int64(len(b))
+}
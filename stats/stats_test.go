@@ -0,0 +1,108 @@
+package stats
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestSize(t *testing.T) {
+	tests := []struct {
+		name string
+		size int64
+		want string
+	}{
+		{"request", requestSize("tools/list", &mcp.ListToolsParams{}, true), `{"jsonrpc":"2.0","id":1,"method":"tools/list","params":{}}`},
+		{"request without params", requestSize("ping", nil, true), `{"jsonrpc":"2.0","id":1,"method":"ping"}`},
+		{"notification", requestSize("notifications/initialized", &mcp.InitializedParams{}, false),
+			`{"jsonrpc":"2.0","method":"notifications/initialized","params":{}}`},
+		{"response", responseSize(&mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: "hi"}}}, nil),
+			`{"jsonrpc":"2.0","id":1,"result":{"content":[{"type":"text","text":"hi"}]}}`},
+		{"error", responseSize(nil, errors.New("boom")), `{"jsonrpc":"2.0","id":1,"error":{"code":0,"message":"boom"}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.size != int64(len(tt.want)) {
+				t.Errorf("size = %d, want %d of %s", tt.size, len(tt.want), tt.want)
+			}
+		})
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	next := func(ctx context.Context, _ *mcp.ClientSession, method string, _ mcp.Params) (mcp.Result, error) {
+		if method == "tools/call" {
+			return nil, errors.New("boom")
+		}
+		return &mcp.ListToolsResult{Tools: []*mcp.Tool{}}, nil
+	}
+	handler := Middleware(next)
+
+	// without stats the requests are only passed on
+	if _, err := handler(context.Background(), nil, "tools/list", &mcp.ListToolsParams{}); err != nil {
+		t.Fatal(err)
+	}
+
+	s := Start()
+	ctx := NewContext(context.Background(), s)
+	for _, method := range []string{"initialize", "tools/list", "tools/call", "notifications/initialized"} {
+		_, _ = handler(ctx, nil, method, &mcp.ListToolsParams{})
+	}
+	var report struct {
+		Requests []struct {
+			Method string `json:"method"`
+			Error  string `json:"error"`
+		} `json:"requests"`
+		Sent     int64 `json:"bytes_sent"`
+		Received int64 `json:"bytes_received"`
+	}
+	var out bytes.Buffer
+	if err := s.Report(&out); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("unmarshal report %s: %v", out.String(), err)
+	}
+	var methods []string
+	for _, r := range report.Requests {
+		methods = append(methods, r.Method)
+	}
+	if got, want := methods, []string{"initialize", "tools/list", "tools/call"}; !slices.Equal(got, want) {
+		t.Fatalf("requests = %q, want %q", got, want)
+	}
+	if report.Requests[2].Error != "boom" {
+		t.Errorf("error of tools/call = %q, want %q", report.Requests[2].Error, "boom")
+	}
+	wantSent := requestSize("initialize", &mcp.ListToolsParams{}, true) + requestSize("tools/list", &mcp.ListToolsParams{}, true) +
+		requestSize("tools/call", &mcp.ListToolsParams{}, true) + requestSize("notifications/initialized", &mcp.ListToolsParams{}, false)
+	if report.Sent != wantSent {
+		t.Errorf("bytes sent = %d, want %d", report.Sent, wantSent)
+	}
+	listed := responseSize(&mcp.ListToolsResult{Tools: []*mcp.Tool{}}, nil)
+	if wantReceived := 2*listed + responseSize(nil, errors.New("boom")); report.Received != wantReceived {
+		t.Errorf("bytes received = %d, want %d", report.Received, wantReceived)
+	}
+}
+
+func TestDuration(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{0, "0s"},
+		{1500 * time.Microsecond, "1.5ms"},
+		{1234567 * time.Nanosecond, "1.235ms"},
+		{2 * time.Second, "2s"},
+	}
+	for _, tt := range tests {
+		if b, _ := Duration(tt.d).MarshalText(); string(b) != tt.want {
+			t.Errorf("Duration(%v) = %s, want %s", tt.d, b, tt.want)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package tui
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonschema"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/yosida95/uritemplate/v3"
+)
+
+// field is an argument of the form, the value is edited as text.
+type field struct {
+	name     string
+	schema   *jsonschema.Schema
+	required bool
+	help     string
+	value    []rune
+}
+
+func (f *field) label() string {
+	label := f.name
+	if f.required {
+		label += "*"
+	}
+	return fmt.Sprintf("%s (%s)", label, schemaType(f.schema))
+}
+
+// form edits the arguments of a call, the fields are followed by the submit button.
+type form struct {
+	title  string
+	fields []*field
+	cursor int
+	err    string
+	submit func(values map[string]any) error
+}
+
+func (f *form) current() *field {
+	if f.cursor < len(f.fields) {
+		return f.fields[f.cursor]
+	}
+	return nil
+}
+
+func (f *form) move(delta int) {
+	f.cursor = max(0, min(f.cursor+delta, len(f.fields)))
+}
+
+// values returns the non-empty values parsed by the types of their schemas.
+func (f *form) values() (map[string]any, error) {
+	values := map[string]any{}
+	for _, field := range f.fields {
+		text := string(field.value)
+		if text == "" {
+			continue
+		}
+		if schemaType(field.schema) == "string" {
+			values[field.name] = text
+			continue
+		}
+		var v any
+		if err := json.Unmarshal([]byte(text), &v); err != nil {
+			return nil, fmt.Errorf("%s: expected %s", field.name, schemaType(field.schema))
+		}
+		values[field.name] = v
+	}
+	return values, nil
+}
+
+// toolForm returns the form of the tool input schema, required properties first.
+func toolForm(tool *mcp.Tool, submit func(map[string]any) error) *form {
+	f := &form{title: "Call tool " + tool.Name, submit: submit}
+	schema := tool.InputSchema
+	if schema == nil {
+		return f
+	}
+	props := slices.Sorted(maps.Keys(schema.Properties))
+	slices.SortStableFunc(props, func(a, b string) int {
+		return cmp.Compare(boolInt(!slices.Contains(schema.Required, a)), boolInt(!slices.Contains(schema.Required, b)))
+	})
+	for _, prop := range props {
+		s := schema.Properties[prop]
+		if s == nil {
+			continue
+		}
+		field := &field{name: prop, schema: s, required: slices.Contains(schema.Required, prop)}
+		var help []string
+		if desc := cmp.Or(s.Description, s.Title); desc != "" {
+			help = append(help, desc)
+		}
+		if len(s.Enum) > 0 {
+			b, _ := json.Marshal(s.Enum)
+			help = append(help, "one of "+string(b))
+		}
+		if len(s.Default) > 0 {
+			help = append(help, "default "+string(s.Default))
+		}
+		if t := schemaType(s); t == "array" || t == "object" {
+			help = append(help, "json "+t)
+		}
+		field.help = strings.Join(help, ", ")
+		f.fields = append(f.fields, field)
+	}
+	return f
+}
+
+// promptForm returns the form of the prompt arguments, required arguments first.
+func promptForm(prompt *mcp.Prompt, submit func(map[string]any) error) *form {
+	f := &form{title: "Get prompt " + prompt.Name, submit: submit}
+	arguments := slices.Clone(prompt.Arguments)
+	slices.SortStableFunc(arguments, func(a, b *mcp.PromptArgument) int {
+		return cmp.Compare(boolInt(!a.Required), boolInt(!b.Required))
+	})
+	for _, arg := range arguments {
+		f.fields = append(f.fields, &field{
+			name:     arg.Name,
+			schema:   &jsonschema.Schema{Type: "string"},
+			required: arg.Required,
+			help:     cmp.Or(arg.Description, arg.Title),
+		})
+	}
+	return f
+}
+
+// templateForm returns the form of the variables of the resource template.
+func templateForm(template *mcp.ResourceTemplate, submit func(map[string]any) error) (*form, error) {
+	t, err := uritemplate.New(template.URITemplate)
+	if err != nil {
+		return nil, fmt.Errorf("parse uri template: %w", err)
+	}
+	f := &form{title: "Read " + template.URITemplate, submit: submit}
+	for _, name := range t.Varnames() {
+		f.fields = append(f.fields, &field{name: name, schema: &jsonschema.Schema{Type: "string"}, required: true})
+	}
+	return f, nil
+}
+
+// expandTemplate returns the uri of the resource template with the values.
+func expandTemplate(template string, values map[string]any) (string, error) {
+	t, err := uritemplate.New(template)
+	if err != nil {
+		return "", fmt.Errorf("parse uri template: %w", err)
+	}
+	vars := uritemplate.Values{}
+	for name, v := range values {
+		vars.Set(name, uritemplate.String(fmt.Sprint(v)))
+	}
+	return t.Expand(vars)
+}
+
+// schemaType returns the first non-null type of the schema, defaults to string.
+func schemaType(schema *jsonschema.Schema) string {
+	if schema == nil {
+		return "string"
+	}
+	if schema.Type != "" {
+		return schema.Type
+	}
+	for _, t := range schema.Types {
+		if t != "null" {
+			return t
+		}
+	}
+	return "string"
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,108 @@
+package tui
+
+import (
+	"bytes"
+	"io"
+	"unicode/utf8"
+)
+
+// key is a key press, either a special key or a printable rune.
+type key struct {
+	special special
+	r       rune
+}
+
+type special int
+
+const (
+	keyRune special = iota
+	keyUp
+	keyDown
+	keyLeft
+	keyRight
+	keyHome
+	keyEnd
+	keyPageUp
+	keyPageDown
+	keyEnter
+	keyTab
+	keyBacktab
+	keyBackspace
+	keyDelete
+	keyEsc
+	keyCtrlC
+)
+
+var sequences = map[string]special{
+	"\033[A": keyUp, "\033OA": keyUp,
+	"\033[B": keyDown, "\033OB": keyDown,
+	"\033[C": keyRight, "\033OC": keyRight,
+	"\033[D": keyLeft, "\033OD": keyLeft,
+	"\033[H": keyHome, "\033OH": keyHome, "\033[1~": keyHome,
+	"\033[F": keyEnd, "\033OF": keyEnd, "\033[4~": keyEnd,
+	"\033[5~": keyPageUp,
+	"\033[6~": keyPageDown,
+	"\033[3~": keyDelete,
+	"\033[Z":  keyBacktab,
+}
+
+// readKeys decodes the key presses read from the terminal in raw mode until it fails.
+// An escape sequence is expected to arrive in a single read, a lone escape is the Esc key.
+func readKeys(r io.Reader, keys chan<- key) {
+	defer close(keys)
+	buf := make([]byte, 256)
+	for {
+		n, err := r.Read(buf)
+		if err != nil {
+			return
+		}
+		for _, k := range decodeKeys(buf[:n]) {
+			keys <- k
+		}
+	}
+}
+
+func decodeKeys(b []byte) (keys []key) {
+	for len(b) > 0 {
+		if b[0] == 033 {
+			if len(b) == 1 {
+				return append(keys, key{special: keyEsc})
+			}
+			matched := false
+			for seq, s := range sequences {
+				if bytes.HasPrefix(b, []byte(seq)) {
+					keys, b, matched = append(keys, key{special: s}), b[len(seq):], true
+					break
+				}
+			}
+			if !matched {
+				// unknown sequence, drop it up to its final byte
+				i := 1
+				for i < len(b) && (b[i] == '[' || b[i] == 'O' || b[i] >= '0' && b[i] <= '9' || b[i] == ';') {
+					i++
+				}
+				b = b[min(i+1, len(b)):]
+			}
+			continue
+		}
+		switch b[0] {
+		case '\r', '\n':
+			keys = append(keys, key{special: keyEnter})
+		case '\t':
+			keys = append(keys, key{special: keyTab})
+		case 127, '\b':
+			keys = append(keys, key{special: keyBackspace})
+		case 3:
+			keys = append(keys, key{special: keyCtrlC})
+		default:
+			r, size := utf8.DecodeRune(b)
+			if r >= ' ' {
+				keys = append(keys, key{r: r})
+			}
+			b = b[size:]
+			continue
+		}
+		b = b[1:]
+	}
+	return keys
+}
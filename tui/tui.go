@@ -0,0 +1,616 @@
+// Package tui is a full-screen terminal browser of the tools, prompts, resources
+// and resource templates of a server.
+package tui
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/cherrydra/mcpurl/mcp/features"
+	"github.com/mattn/go-runewidth"
+	"github.com/mcpurl/readline"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+var ErrNoTerminal = errors.New("the tui needs a terminal")
+
+type tab int
+
+const (
+	tabTools tab = iota
+	tabPrompts
+	tabResources
+	tabTemplates
+)
+
+var tabNames = []string{"Tools", "Prompts", "Resources", "Templates"}
+
+type focus int
+
+const (
+	focusList focus = iota
+	focusDetail
+	focusResults
+)
+
+// item is an entry of a list, value is the tool, prompt, resource or resource template.
+type item struct {
+	name  string
+	desc  string
+	value any
+}
+
+type list struct {
+	items    []item
+	loaded   bool
+	selected int
+	offset   int
+}
+
+// TUI browses the server features, the terminal is in raw mode while it runs.
+type TUI struct {
+	Features features.ServerFeatures
+	// Server is the display name of the server.
+	Server string
+	// Yes calls destructive tools without confirmation.
+	Yes bool
+
+	keys    chan key
+	width   int
+	height  int
+	tab     tab
+	lists   [4]list
+	focus   focus
+	detail  viewer
+	results viewer
+	form    *form
+	// filter narrows the list to the items containing it, filtering is set while it is typed.
+	filter    string
+	filtering bool
+	status    string
+}
+
+// Run shows the browser until it is quit with q.
+func (t *TUI) Run(ctx context.Context) error {
+	fd := int(os.Stdin.Fd())
+	if !readline.IsTerminal(fd) || !readline.IsTerminal(int(os.Stdout.Fd())) {
+		return ErrNoTerminal
+	}
+	state, err := readline.MakeRaw(fd)
+	if err != nil {
+		return fmt.Errorf("make raw terminal: %w", err)
+	}
+	defer readline.Restore(fd, state)
+	// alternate screen without cursor
+	fmt.Fprint(os.Stdout, "\033[?1049h\033[?25l")
+	defer fmt.Fprint(os.Stdout, "\033[?25h\033[?1049l")
+
+	t.keys = make(chan key)
+	go readKeys(os.Stdin, t.keys)
+	resized := make(chan struct{}, 1)
+	readline.DefaultOnWidthChanged(func() {
+		select {
+		case resized <- struct{}{}:
+		default:
+		}
+	})
+
+	t.results.setText("Results", "Press Enter on an item to call it.")
+	t.load(ctx)
+	for {
+		t.draw()
+		select {
+		case k, ok := <-t.keys:
+			if !ok || t.handle(ctx, k) {
+				return nil
+			}
+		case <-resized:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// load lists the items of the current tab once.
+func (t *TUI) load(ctx context.Context) {
+	l := &t.lists[t.tab]
+	if l.loaded {
+		t.showDetail()
+		return
+	}
+	l.items, l.selected, l.offset = nil, 0, 0
+	var err error
+	switch t.tab {
+	case tabTools:
+		var tools []*mcp.Tool
+		tools, err = t.Features.ListTools(ctx)
+		for _, tool := range tools {
+			l.items = append(l.items, item{tool.Name, tool.Description, tool})
+		}
+	case tabPrompts:
+		var prompts []*mcp.Prompt
+		prompts, err = t.Features.ListPrompts(ctx)
+		for _, prompt := range prompts {
+			l.items = append(l.items, item{prompt.Name, prompt.Description, prompt})
+		}
+	case tabResources:
+		var resources []*mcp.Resource
+		resources, err = t.Features.ListResources(ctx)
+		for _, resource := range resources {
+			l.items = append(l.items, item{cmp.Or(resource.Name, resource.URI), resource.Description, resource})
+		}
+	case tabTemplates:
+		var templates []*mcp.ResourceTemplate
+		templates, err = t.Features.ListResourceTemplates(ctx)
+		for _, template := range templates {
+			l.items = append(l.items, item{cmp.Or(template.Name, template.URITemplate), template.Description, template})
+		}
+	}
+	l.loaded = err == nil
+	if err != nil {
+		t.detail.setText(tabNames[t.tab], fmt.Sprintf("Error: %v", err))
+		return
+	}
+	t.showDetail()
+}
+
+// visible returns the indexes of the items matching the filter.
+func (t *TUI) visible() []int {
+	var ret []int
+	filter := strings.ToLower(t.filter)
+	for i, it := range t.lists[t.tab].items {
+		if filter == "" || strings.Contains(strings.ToLower(it.name+" "+it.desc), filter) {
+			ret = append(ret, i)
+		}
+	}
+	return ret
+}
+
+// selected returns the selected item of the current list.
+func (t *TUI) selected() (item, bool) {
+	l := &t.lists[t.tab]
+	visible := t.visible()
+	if len(visible) == 0 {
+		return item{}, false
+	}
+	l.selected = max(0, min(l.selected, len(visible)-1))
+	return l.items[visible[l.selected]], true
+}
+
+// showDetail shows the selected item as json, with the schemas and annotations.
+func (t *TUI) showDetail() {
+	it, ok := t.selected()
+	if !ok {
+		t.detail.setText(tabNames[t.tab], "Nothing here.")
+		return
+	}
+	b, err := json.Marshal(it.value)
+	if err != nil {
+		t.detail.setText(it.name, err.Error())
+		return
+	}
+	title := it.name
+	if tool, ok := it.value.(*mcp.Tool); ok {
+		if hints := features.Hints(tool); len(hints) > 0 {
+			title += "  [" + strings.Join(hints, ", ") + "]"
+		}
+	}
+	t.detail.set(title, b)
+}
+
+// handle handles the key, it returns true to quit.
+func (t *TUI) handle(ctx context.Context, k key) bool {
+	t.status = ""
+	if t.form != nil {
+		t.handleForm(ctx, k)
+		return false
+	}
+	if t.filtering {
+		t.handleFilter(k)
+		return false
+	}
+	v := t.viewer()
+	switch k.special {
+	case keyCtrlC:
+		return true
+	case keyTab:
+		t.focus = (t.focus + 1) % 3
+	case keyBacktab:
+		t.focus = (t.focus + 2) % 3
+	case keyLeft:
+		t.switchTab(ctx, (t.tab+3)%4)
+	case keyRight:
+		t.switchTab(ctx, (t.tab+1)%4)
+	case keyUp:
+		t.move(v, -1)
+	case keyDown:
+		t.move(v, 1)
+	case keyPageUp:
+		t.move(v, -t.pageSize())
+	case keyPageDown:
+		t.move(v, t.pageSize())
+	case keyHome:
+		t.move(v, -1<<30)
+	case keyEnd:
+		t.move(v, 1<<30)
+	case keyEsc:
+		if t.filter != "" {
+			t.filter = ""
+			t.showDetail()
+		}
+	case keyEnter:
+		if v != nil {
+			v.toggle()
+		} else {
+			t.invoke(ctx)
+		}
+	}
+	switch k.r {
+	case 'q':
+		return true
+	case '1', '2', '3', '4':
+		t.switchTab(ctx, tab(k.r-'1'))
+	case ' ':
+		if v != nil {
+			v.toggle()
+		}
+	case '-', '+':
+		if v != nil {
+			v.foldAll(k.r == '-')
+		}
+	case '/':
+		t.focus, t.filtering, t.filter = focusList, true, ""
+		t.showDetail()
+	case 'r':
+		t.Features.Refresh()
+		t.lists = [4]list{}
+		t.load(ctx)
+	}
+	return false
+}
+
+func (t *TUI) handleFilter(k key) {
+	switch k.special {
+	case keyEnter, keyEsc, keyCtrlC:
+		t.filtering = false
+		if k.special != keyEnter {
+			t.filter = ""
+		}
+	case keyBackspace:
+		if r := []rune(t.filter); len(r) > 0 {
+			t.filter = string(r[:len(r)-1])
+		}
+	case keyRune:
+		t.filter += string(k.r)
+	}
+	t.lists[t.tab].selected = 0
+	t.showDetail()
+}
+
+func (t *TUI) handleForm(ctx context.Context, k key) {
+	f := t.form
+	switch k.special {
+	case keyEsc, keyCtrlC:
+		t.form = nil
+	case keyUp, keyBacktab:
+		f.move(-1)
+	case keyDown, keyTab:
+		f.move(1)
+	case keyEnter:
+		if f.current() != nil {
+			f.move(1)
+			return
+		}
+		values, err := f.values()
+		if err == nil {
+			err = f.submit(values)
+		}
+		if err != nil {
+			f.err = err.Error()
+			return
+		}
+		t.form = nil
+	case keyBackspace:
+		if field := f.current(); field != nil && len(field.value) > 0 {
+			field.value = field.value[:len(field.value)-1]
+		}
+	case keyRune:
+		if field := f.current(); field != nil {
+			field.value = append(field.value, k.r)
+		}
+	}
+}
+
+// viewer returns the focused viewer, nil if the list is focused.
+func (t *TUI) viewer() *viewer {
+	switch t.focus {
+	case focusDetail:
+		return &t.detail
+	case focusResults:
+		return &t.results
+	}
+	return nil
+}
+
+func (t *TUI) move(v *viewer, delta int) {
+	if v != nil {
+		v.move(delta)
+		return
+	}
+	l := &t.lists[t.tab]
+	l.selected = max(0, min(l.selected+delta, len(t.visible())-1))
+	t.showDetail()
+}
+
+func (t *TUI) switchTab(ctx context.Context, tab tab) {
+	t.tab, t.focus, t.filter = tab, focusList, ""
+	t.load(ctx)
+}
+
+// invoke calls the selected tool, prompt or template through a form, resources are read directly.
+func (t *TUI) invoke(ctx context.Context) {
+	it, ok := t.selected()
+	if !ok {
+		return
+	}
+	switch v := it.value.(type) {
+	case *mcp.Tool:
+		t.form = toolForm(v, func(values map[string]any) error {
+			if err := features.Validate("arguments", v.InputSchema, values); err != nil {
+				return err
+			}
+			t.call(it.name, func(f features.ServerFeatures) error {
+				return f.CallTool1(ctx, v.Name, values)
+			})
+			return nil
+		})
+	case *mcp.Prompt:
+		t.form = promptForm(v, func(values map[string]any) error {
+			params := map[string]string{}
+			for name, value := range values {
+				params[name] = fmt.Sprint(value)
+			}
+			t.call(it.name, func(f features.ServerFeatures) error {
+				return f.GetPrompt1(ctx, v.Name, params)
+			})
+			return nil
+		})
+	case *mcp.Resource:
+		t.call(v.URI, func(f features.ServerFeatures) error {
+			return f.ReadResource(ctx, v.URI)
+		})
+	case *mcp.ResourceTemplate:
+		form, err := templateForm(v, func(values map[string]any) error {
+			uri, err := expandTemplate(v.URITemplate, values)
+			if err != nil {
+				return err
+			}
+			t.call(uri, func(f features.ServerFeatures) error {
+				return f.ReadResource(ctx, uri)
+			})
+			return nil
+		})
+		if err != nil {
+			t.status = err.Error()
+			return
+		}
+		t.form = form
+	}
+}
+
+// call runs the call with its output shown in the results.
+func (t *TUI) call(title string, run func(f features.ServerFeatures) error) {
+	t.status = "Calling " + title + "..."
+	t.draw()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.results.setText(title, fmt.Sprintf("Error: create pipe: %v", err))
+		return
+	}
+	output := make(chan []byte, 1)
+	go func() {
+		defer r.Close()
+		b, _ := io.ReadAll(r)
+		output <- b
+	}()
+	f := t.Features
+	f.Out, f.Confirm = w, t.confirm
+	err = run(f)
+	w.Close()
+	t.results.set(title, <-output)
+	if err != nil {
+		t.results.appendText(fmt.Sprintf("Error: %v", err))
+	}
+	t.status, t.focus = "", focusResults
+}
+
+var ansi = regexp.MustCompile("\033\\[[0-9;]*m")
+
+// confirm asks on the status line, y confirms.
+func (t *TUI) confirm(prompt string) (bool, error) {
+	if t.Yes {
+		return true, nil
+	}
+	t.status = ansi.ReplaceAllString(prompt, "")
+	t.draw()
+	k, ok := <-t.keys
+	t.status = ""
+	return ok && (k.r == 'y' || k.r == 'Y'), nil
+}
+
+func (t *TUI) pageSize() int {
+	return max(1, t.height/2-2)
+}
+
+// draw renders the whole screen: the tabs, the list, the detail and results panes and the status line.
+func (t *TUI) draw() {
+	t.width, t.height, _ = readline.GetSize(int(os.Stdout.Fd()))
+	t.width, t.height = max(t.width, 40), max(t.height, 10)
+	listWidth := min(max(24, t.width/3), 48)
+	rightWidth := t.width - listWidth - 1
+	body := t.height - 2
+
+	var b strings.Builder
+	b.WriteString("\033[H")
+
+	// tabs
+	var header strings.Builder
+	header.WriteString(" mcpurl " + t.Server + "  ")
+	for i, name := range tabNames {
+		label := fmt.Sprintf(" %d %s ", i+1, name)
+		if l := t.lists[i]; l.loaded {
+			label = fmt.Sprintf(" %d %s (%d) ", i+1, name, len(l.items))
+		}
+		if tab(i) == t.tab {
+			label = "\033[7m" + label + "\033[0m"
+		}
+		header.WriteString(label)
+	}
+	t.row(&b, 1, header.String())
+
+	left := t.listLines(body, listWidth)
+	var right []string
+	if t.form != nil {
+		right = t.formLines(body, rightWidth)
+	} else {
+		detailRows := (body - 2) / 2
+		right = append(right, t.paneTitle(t.detail.title, rightWidth, t.focus == focusDetail))
+		right = append(right, t.viewerLines(&t.detail, detailRows, rightWidth, t.focus == focusDetail)...)
+		right = append(right, t.paneTitle(t.results.title, rightWidth, t.focus == focusResults))
+		right = append(right, t.viewerLines(&t.results, body-2-detailRows, rightWidth, t.focus == focusResults)...)
+	}
+	for i := range body {
+		t.row(&b, i+2, left[i]+"\033[2m│\033[0m"+right[i])
+	}
+
+	status := t.status
+	switch {
+	case status != "":
+	case t.form != nil:
+		status = "↑/↓ field  type to edit  Enter next/submit  Esc cancel"
+	case t.filtering:
+		status = "Type to filter  Enter done  Esc clear"
+	default:
+		status = "←/→ tab  Tab pane  ↑/↓ move  Enter call/fold  Space fold  -/+ fold all  / filter  r refresh  q quit"
+	}
+	t.row(&b, t.height, "\033[7m"+fit(" "+status, t.width-1)+"\033[0m")
+	fmt.Fprint(os.Stdout, b.String())
+}
+
+// row writes the line at the row, clearing the rest of it.
+func (t *TUI) row(b *strings.Builder, row int, s string) {
+	fmt.Fprintf(b, "\033[%d;1H%s\033[0m\033[K", row, s)
+}
+
+func (t *TUI) paneTitle(title string, width int, focused bool) string {
+	if focused {
+		return "\033[7m" + fit(" "+title, width) + "\033[0m"
+	}
+	return "\033[1m" + fit(" "+title, width) + "\033[0m"
+}
+
+func (t *TUI) listLines(rows, width int) []string {
+	l := &t.lists[t.tab]
+	title := tabNames[t.tab]
+	if t.filtering || t.filter != "" {
+		title = "/" + t.filter
+		if t.filtering {
+			title += "█"
+		}
+	}
+	lines := []string{t.paneTitle(title, width, t.focus == focusList)}
+	visible := t.visible()
+	if l.selected < l.offset {
+		l.offset = l.selected
+	}
+	if l.selected >= l.offset+rows-1 {
+		l.offset = l.selected - rows + 2
+	}
+	for i := l.offset; i < len(visible) && len(lines) < rows; i++ {
+		it := l.items[visible[i]]
+		text := fit(" "+it.name, width)
+		if i == l.selected {
+			style := "\033[1;36m"
+			if t.focus == focusList {
+				style = "\033[7m"
+			}
+			text = style + text + "\033[0m"
+		}
+		lines = append(lines, text)
+	}
+	for len(lines) < rows {
+		lines = append(lines, fit("", width))
+	}
+	return lines
+}
+
+func (t *TUI) viewerLines(v *viewer, rows, width int, focused bool) []string {
+	view, cursor := v.view(rows)
+	var lines []string
+	for i, text := range view {
+		text = fit(text, width)
+		if focused && i == cursor {
+			text = "\033[7m" + text + "\033[0m"
+		}
+		lines = append(lines, text)
+	}
+	for len(lines) < rows {
+		lines = append(lines, fit("", width))
+	}
+	return lines
+}
+
+func (t *TUI) formLines(rows, width int) []string {
+	f := t.form
+	lines := []string{t.paneTitle(f.title, width, true), fit("", width)}
+	labelWidth := 0
+	for _, field := range f.fields {
+		labelWidth = max(labelWidth, runewidth.StringWidth(field.label()))
+	}
+	for i, field := range f.fields {
+		value := string(field.value)
+		if i == f.cursor {
+			value += "█"
+		}
+		text := fit(fmt.Sprintf(" %-*s  %s", labelWidth, field.label(), value), width)
+		if i == f.cursor {
+			text = "\033[1m" + text + "\033[0m"
+		}
+		lines = append(lines, text)
+	}
+	button := fit("   [ Submit ]", width)
+	if f.cursor == len(f.fields) {
+		button = "\033[7m" + button + "\033[0m"
+	}
+	lines = append(lines, fit("", width), button, fit("", width))
+	if field := f.current(); field != nil && field.help != "" {
+		lines = append(lines, "\033[2m"+fit(" "+field.help, width)+"\033[0m")
+	}
+	if f.err != "" {
+		for _, l := range strings.Split(f.err, "\n") {
+			lines = append(lines, "\033[31m"+fit(" "+l, width)+"\033[0m")
+		}
+	}
+	for len(lines) < rows {
+		lines = append(lines, fit("", width))
+	}
+	return lines[:rows]
+}
+
+// fit truncates or pads the text to the width, control characters are shown as spaces.
+func fit(s string, width int) string {
+	s = strings.Map(func(r rune) rune {
+		if r < ' ' {
+			return ' '
+		}
+		return r
+	}, s)
+	return runewidth.FillRight(runewidth.Truncate(s, width, "…"), width)
+}
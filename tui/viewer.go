@@ -0,0 +1,213 @@
+package tui
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// node is a json value shown by the viewer, objects and arrays can be folded.
+type node struct {
+	// key is the quoted object key followed by a colon, empty for array items and top level values.
+	key      string
+	raw      string
+	delim    byte // '{' or '[' for objects and arrays
+	children []*node
+	folded   bool
+}
+
+// line is a line of the viewer, node is the object or array opened or closed on the line.
+type line struct {
+	text    string
+	node    *node
+	opening bool
+}
+
+// viewer shows json values with folding, other text is shown as is.
+type viewer struct {
+	title  string
+	roots  []*node
+	lines  []line
+	cursor int
+	offset int
+}
+
+// set replaces the content of the viewer with the json values, or the text if it is not json.
+func (v *viewer) set(title string, data []byte) {
+	v.title, v.roots, v.cursor, v.offset = title, parseNodes(data), 0, 0
+	v.layout()
+}
+
+// setText replaces the content of the viewer with the text.
+func (v *viewer) setText(title, text string) {
+	v.title, v.roots, v.cursor, v.offset = title, textNodes(text), 0, 0
+	v.layout()
+}
+
+// appendText adds the text lines after the content.
+func (v *viewer) appendText(text string) {
+	v.roots = append(v.roots, textNodes(text)...)
+	v.layout()
+}
+
+func parseNodes(data []byte) []*node {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var roots []*node
+	for {
+		n, err := decodeNode(dec, "")
+		if err == io.EOF {
+			return roots
+		}
+		if err != nil {
+			return textNodes(string(data))
+		}
+		roots = append(roots, n)
+	}
+}
+
+func decodeNode(dec *json.Decoder, key string) (*node, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	n := &node{key: key}
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		b, err := json.Marshal(tok)
+		if err != nil {
+			return nil, err
+		}
+		n.raw = string(b)
+		return n, nil
+	}
+	n.delim = byte(delim)
+	for dec.More() {
+		childKey := ""
+		if n.delim == '{' {
+			tok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			b, _ := json.Marshal(tok)
+			childKey = string(b) + ": "
+		}
+		child, err := decodeNode(dec, childKey)
+		if err != nil {
+			return nil, err
+		}
+		n.children = append(n.children, child)
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+func textNodes(text string) []*node {
+	var nodes []*node
+	for _, l := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		nodes = append(nodes, &node{raw: l})
+	}
+	return nodes
+}
+
+// layout rebuilds the visible lines after folding.
+func (v *viewer) layout() {
+	v.lines = v.lines[:0]
+	for _, n := range v.roots {
+		v.flatten(n, 0, "")
+	}
+	v.cursor = max(0, min(v.cursor, len(v.lines)-1))
+}
+
+func (v *viewer) flatten(n *node, depth int, comma string) {
+	indent := strings.Repeat("  ", depth)
+	if n.delim == 0 {
+		v.lines = append(v.lines, line{text: indent + n.key + n.raw + comma})
+		return
+	}
+	closing := "}"
+	if n.delim == '[' {
+		closing = "]"
+	}
+	if len(n.children) == 0 {
+		v.lines = append(v.lines, line{text: indent + n.key + string(n.delim) + closing + comma})
+		return
+	}
+	if n.folded {
+		text := fmt.Sprintf("%s%s%c…%s%s  (%d)", indent, n.key, n.delim, closing, comma, len(n.children))
+		v.lines = append(v.lines, line{text, n, true})
+		return
+	}
+	v.lines = append(v.lines, line{indent + n.key + string(n.delim), n, true})
+	for i, child := range n.children {
+		childComma := ","
+		if i == len(n.children)-1 {
+			childComma = ""
+		}
+		v.flatten(child, depth+1, childComma)
+	}
+	v.lines = append(v.lines, line{indent + closing + comma, n, false})
+}
+
+// toggle folds or unfolds the object or array at the cursor.
+func (v *viewer) toggle() {
+	if v.cursor >= len(v.lines) || v.lines[v.cursor].node == nil {
+		return
+	}
+	n := v.lines[v.cursor].node
+	n.folded = !n.folded
+	v.layout()
+	for i, l := range v.lines {
+		if l.node == n && l.opening {
+			v.cursor = i
+			break
+		}
+	}
+}
+
+// foldAll folds the objects and arrays below the top level values, or unfolds all of them.
+func (v *viewer) foldAll(folded bool) {
+	var walk func(n *node, depth int)
+	walk = func(n *node, depth int) {
+		if n.delim != 0 {
+			n.folded = folded && depth > 0
+		}
+		for _, child := range n.children {
+			walk(child, depth+1)
+		}
+	}
+	for _, n := range v.roots {
+		walk(n, 0)
+	}
+	v.cursor = 0
+	v.layout()
+}
+
+func (v *viewer) move(delta int) {
+	v.cursor = max(0, min(v.cursor+delta, len(v.lines)-1))
+}
+
+// view returns the visible lines of the viewer scrolled to show the cursor.
+func (v *viewer) view(rows int) ([]string, int) {
+	if v.cursor < v.offset {
+		v.offset = v.cursor
+	}
+	if v.cursor >= v.offset+rows {
+		v.offset = v.cursor - rows + 1
+	}
+	var ret []string
+	for i := v.offset; i < len(v.lines) && i < v.offset+rows; i++ {
+		marker := "  "
+		if l := v.lines[i]; l.opening && l.node.folded {
+			marker = "▸ "
+		} else if l.opening {
+			marker = "▾ "
+		}
+		ret = append(ret, marker+v.lines[i].text)
+	}
+	return ret, v.cursor - v.offset
+}